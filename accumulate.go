@@ -0,0 +1,240 @@
+package dify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrStreamIncomplete 流在结束事件之前中断
+var ErrStreamIncomplete = errors.New("stream ended before final event")
+
+// EventHandler 流式事件回调, 返回错误时停止读取
+type EventHandler func(event Event) error
+
+// messageAccumulator 聚合 message 类事件
+type messageAccumulator struct {
+	answer         strings.Builder
+	taskID         string
+	messageID      string
+	conversationID string
+	createdAt      int64
+	metadata       Metadata
+	finished       bool
+}
+
+// handle 处理单个事件
+func (a *messageAccumulator) handle(event Event) error {
+	switch e := event.(type) {
+	case *MessageStreamEvent:
+		a.answer.WriteString(e.Answer)
+		a.update(e.TaskID, e.MessageID, e.ConversationID, e.CreatedAt)
+	case *AgentMessageEvent:
+		a.answer.WriteString(e.Answer)
+		a.update(e.TaskID, e.MessageID, e.ConversationID, e.CreatedAt)
+	case *MessageReplaceEvent:
+		a.answer.Reset()
+		a.answer.WriteString(e.Answer)
+		a.update(e.TaskID, e.MessageID, e.ConversationID, e.CreatedAt)
+	case *MessageEndStreamEvent:
+		a.metadata = e.Metadata
+		a.update(e.TaskID, e.MessageID, e.ConversationID, 0)
+		a.finished = true
+	case *ErrorStreamEvent:
+		return newStreamError(e)
+	}
+	return nil
+}
+
+// update 记录消息标识
+func (a *messageAccumulator) update(taskID, messageID, conversationID string, createdAt int64) {
+	if taskID != "" {
+		a.taskID = taskID
+	}
+	if messageID != "" {
+		a.messageID = messageID
+	}
+	if conversationID != "" {
+		a.conversationID = conversationID
+	}
+	if createdAt != 0 && a.createdAt == 0 {
+		a.createdAt = createdAt
+	}
+}
+
+// consumeStream 读取流直到结束, 每个事件依次交给 handler 和回调
+func consumeStream(stream *StreamReader, handler EventHandler, onEvent EventHandler) error {
+	for {
+		event, err := stream.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if onEvent != nil {
+			if err := onEvent(event); err != nil {
+				return err
+			}
+		}
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+}
+
+// AccumulateChat 读取对话流并组装与阻塞模式一致的 ChatResponse
+//
+// onEvent 可为 nil, 不为 nil 时每个事件都会先传给它 (例如用于实时输出增量内容)。
+// 调用方仍需负责关闭 stream。
+func AccumulateChat(stream *StreamReader, onEvent EventHandler) (*ChatResponse, error) {
+	var acc messageAccumulator
+	if err := consumeStream(stream, acc.handle, onEvent); err != nil {
+		return nil, err
+	}
+	if !acc.finished {
+		return nil, ErrStreamIncomplete
+	}
+
+	return &ChatResponse{
+		MessageID:      acc.messageID,
+		ConversationID: acc.conversationID,
+		Answer:         acc.answer.String(),
+		Metadata:       acc.metadata,
+		CreatedAt:      acc.createdAt,
+	}, nil
+}
+
+// AccumulateCompletion 读取文本生成流并组装与阻塞模式一致的 CompletionResponse
+//
+// onEvent 可为 nil。调用方仍需负责关闭 stream。
+func AccumulateCompletion(stream *StreamReader, onEvent EventHandler) (*CompletionResponse, error) {
+	var acc messageAccumulator
+	if err := consumeStream(stream, acc.handle, onEvent); err != nil {
+		return nil, err
+	}
+	if !acc.finished {
+		return nil, ErrStreamIncomplete
+	}
+
+	return &CompletionResponse{
+		MessageID: acc.messageID,
+		Answer:    acc.answer.String(),
+		Metadata:  acc.metadata,
+		CreatedAt: acc.createdAt,
+	}, nil
+}
+
+// AccumulateWorkflow 读取工作流流并组装与阻塞模式一致的 WorkflowResponse
+//
+// onEvent 可为 nil。调用方仍需负责关闭 stream。
+func AccumulateWorkflow(stream *StreamReader, onEvent EventHandler) (*WorkflowResponse, error) {
+	var resp *WorkflowResponse
+	handler := func(event Event) error {
+		switch e := event.(type) {
+		case *WorkflowFinishedEvent:
+			resp = &WorkflowResponse{
+				WorkflowRunID: e.WorkflowRunID,
+				TaskID:        e.TaskID,
+				Data:          e.Data,
+			}
+		case *ErrorStreamEvent:
+			return newStreamError(e)
+		}
+		return nil
+	}
+
+	if err := consumeStream(stream, handler, onEvent); err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, ErrStreamIncomplete
+	}
+	return resp, nil
+}
+
+// AgentThoughtAccumulator 合并 agent_thought 事件的增量更新
+//
+// Dify 对同一思考步骤会以相同 id 推送多次, 后续事件携带更新后的内容;
+// 累加器按 id (缺失时按 position) 合并, 非空字段覆盖旧值。
+type AgentThoughtAccumulator struct {
+	thoughts []AgentThought
+	index    map[string]int
+}
+
+// Add 合并一个 agent_thought 事件
+func (a *AgentThoughtAccumulator) Add(e *AgentThoughtEvent) {
+	if a.index == nil {
+		a.index = make(map[string]int)
+	}
+
+	key := e.ID
+	if key == "" {
+		key = fmt.Sprintf("position:%d", e.Position)
+	}
+
+	i, ok := a.index[key]
+	if !ok {
+		a.index[key] = len(a.thoughts)
+		a.thoughts = append(a.thoughts, e.AgentThought)
+		return
+	}
+	mergeAgentThought(&a.thoughts[i], &e.AgentThought)
+}
+
+// Handle 作为 EventHandler 使用, 忽略非 agent_thought 事件
+func (a *AgentThoughtAccumulator) Handle(event Event) error {
+	if e, ok := event.(*AgentThoughtEvent); ok {
+		a.Add(e)
+	}
+	return nil
+}
+
+// Thoughts 返回按 position 排序的思考步骤
+func (a *AgentThoughtAccumulator) Thoughts() []AgentThought {
+	thoughts := slices.Clone(a.thoughts)
+	slices.SortStableFunc(thoughts, func(x, y AgentThought) int {
+		return x.Position - y.Position
+	})
+	return thoughts
+}
+
+// mergeAgentThought 将 src 的非空字段合并到 dst
+func mergeAgentThought(dst, src *AgentThought) {
+	if src.ChainID != "" {
+		dst.ChainID = src.ChainID
+	}
+	if src.MessageID != "" {
+		dst.MessageID = src.MessageID
+	}
+	if src.Position != 0 {
+		dst.Position = src.Position
+	}
+	if src.Thought != "" {
+		dst.Thought = src.Thought
+	}
+	if src.Tool != "" {
+		dst.Tool = src.Tool
+	}
+	if src.ToolLabels != nil {
+		dst.ToolLabels = src.ToolLabels
+	}
+	if src.ToolInput != "" {
+		dst.ToolInput = src.ToolInput
+	}
+	if src.Observation != "" {
+		dst.Observation = src.Observation
+	}
+	if len(src.MessageFiles) > 0 {
+		dst.MessageFiles = src.MessageFiles
+	}
+	if len(src.Files) > 0 {
+		dst.Files = src.Files
+	}
+	if src.CreatedAt != 0 {
+		dst.CreatedAt = src.CreatedAt
+	}
+}
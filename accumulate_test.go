@@ -0,0 +1,137 @@
+package dify
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccumulateChat(t *testing.T) {
+	stream := newTestStream("" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\"Hello\",\"created_at\":100}\n\n" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\", wor\",\"created_at\":101}\n\n" +
+		// 内容审查替换已输出的内容
+		"data: {\"event\":\"message_replace\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\"[redacted]\"}\n\n" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\" ok\"}\n\n" +
+		"data: {\"event\":\"message_end\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"metadata\":{\"usage\":{\"total_tokens\":12}}}\n\n")
+
+	var seen int
+	resp, err := AccumulateChat(stream, func(event Event) error {
+		seen++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AccumulateChat() error = %v", err)
+	}
+
+	if resp.Answer != "[redacted] ok" {
+		t.Errorf("Answer = %q, want %q", resp.Answer, "[redacted] ok")
+	}
+	if resp.MessageID != "m" || resp.ConversationID != "c" || resp.CreatedAt != 100 {
+		t.Errorf("response = %+v, want message m, conversation c, created_at 100", resp)
+	}
+	if resp.Metadata.Usage.TotalTokens != 12 {
+		t.Errorf("TotalTokens = %d, want 12", resp.Metadata.Usage.TotalTokens)
+	}
+	if seen != 5 {
+		t.Errorf("onEvent calls = %d, want 5", seen)
+	}
+}
+
+func TestAccumulateIncomplete(t *testing.T) {
+	// 连接在结束事件之前断开
+	const truncated = "" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"answer\":\"Hel\"}\n\n" +
+		"data: {\"event\":\"workflow_started\",\"task_id\":\"t\",\"workflow_run_id\":\"run\",\"data\":{}}\n\n" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"answer\":\"lo\"}\n\n"
+
+	tests := []struct {
+		name       string
+		accumulate func(*StreamReader) error
+	}{
+		{name: "chat", accumulate: func(sr *StreamReader) error {
+			_, err := AccumulateChat(sr, nil)
+			return err
+		}},
+		{name: "completion", accumulate: func(sr *StreamReader) error {
+			_, err := AccumulateCompletion(sr, nil)
+			return err
+		}},
+		{name: "workflow", accumulate: func(sr *StreamReader) error {
+			_, err := AccumulateWorkflow(sr, nil)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, sse := range []string{truncated, truncated + "data: {\"event\":\"mess"} {
+				if err := tt.accumulate(newTestStream(sse)); !errors.Is(err, ErrStreamIncomplete) {
+					t.Errorf("error = %v, want ErrStreamIncomplete", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAccumulateWorkflow(t *testing.T) {
+	stream := newTestStream("" +
+		"data: {\"event\":\"workflow_started\",\"task_id\":\"t\",\"workflow_run_id\":\"run\",\"data\":{\"id\":\"run\"}}\n\n" +
+		"data: {\"event\":\"node_started\",\"task_id\":\"t\",\"workflow_run_id\":\"run\",\"data\":{\"id\":\"e-1\",\"node_id\":\"llm\"}}\n\n" +
+		"data: {\"event\":\"text_chunk\",\"task_id\":\"t\",\"workflow_run_id\":\"run\",\"data\":{\"text\":\"hi\"}}\n\n" +
+		"data: {\"event\":\"workflow_finished\",\"task_id\":\"t\",\"workflow_run_id\":\"run\",\"data\":{\"id\":\"run\",\"status\":\"succeeded\",\"outputs\":{\"text\":\"hi\"},\"total_tokens\":3}}\n\n")
+
+	resp, err := AccumulateWorkflow(stream, nil)
+	if err != nil {
+		t.Fatalf("AccumulateWorkflow() error = %v", err)
+	}
+	if resp.WorkflowRunID != "run" || resp.TaskID != "t" {
+		t.Errorf("response = %+v, want run and task t", resp)
+	}
+	if resp.Data.Status != WorkflowStatusSucceeded || resp.Data.Outputs["text"] != "hi" || resp.Data.TotalTokens != 3 {
+		t.Errorf("Data = %+v", resp.Data)
+	}
+}
+
+func TestAccumulateCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	stream := newTestStream("" +
+		"data: {\"event\":\"message\",\"message_id\":\"m\",\"answer\":\"a\"}\n\n" +
+		"data: {\"event\":\"message_end\",\"message_id\":\"m\"}\n\n")
+
+	_, err := AccumulateCompletion(stream, func(event Event) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("error = %v, want callback error", err)
+	}
+}
+
+func TestAccumulateChatAgent(t *testing.T) {
+	stream := newTestStream("" +
+		"data: {\"event\":\"agent_thought\",\"id\":\"th-1\",\"message_id\":\"m\",\"position\":1,\"thought\":\"\",\"tool\":\"search\",\"tool_input\":\"{}\"}\n\n" +
+		"data: {\"event\":\"agent_message\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\"Looking\"}\n\n" +
+		"data: {\"event\":\"agent_thought\",\"id\":\"th-1\",\"message_id\":\"m\",\"position\":1,\"thought\":\"found it\",\"observation\":\"42\"}\n\n" +
+		"data: {\"event\":\"agent_message\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\",\"answer\":\" up: 42\"}\n\n" +
+		"data: {\"event\":\"agent_thought\",\"id\":\"th-2\",\"message_id\":\"m\",\"position\":2,\"thought\":\"done\"}\n\n" +
+		"data: {\"event\":\"message_end\",\"task_id\":\"t\",\"message_id\":\"m\",\"conversation_id\":\"c\"}\n\n")
+
+	var thoughts AgentThoughtAccumulator
+	resp, err := AccumulateChat(stream, thoughts.Handle)
+	if err != nil {
+		t.Fatalf("AccumulateChat() error = %v", err)
+	}
+	if resp.Answer != "Looking up: 42" || resp.ConversationID != "c" {
+		t.Errorf("response = %+v, want answer %q", resp, "Looking up: 42")
+	}
+
+	got := thoughts.Thoughts()
+	if len(got) != 2 {
+		t.Fatalf("thoughts = %d, want 2", len(got))
+	}
+	if got[0].ID != "th-1" || got[0].Tool != "search" || got[0].Thought != "found it" || got[0].Observation != "42" {
+		t.Errorf("thought 1 = %+v, want merged search step", got[0])
+	}
+	if got[1].ID != "th-2" || got[1].Thought != "done" {
+		t.Errorf("thought 2 = %+v", got[1])
+	}
+}
@@ -0,0 +1,121 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AnnotationJobFailedError 标注回复任务执行失败
+type AnnotationJobFailedError struct {
+	JobID   string
+	Message string
+}
+
+func (e *AnnotationJobFailedError) Error() string {
+	return fmt.Sprintf("dify annotation job failed: job_id=%s, error=%s", e.JobID, e.Message)
+}
+
+// ListAnnotations 获取标注列表
+func (c *ChatClient) ListAnnotations(ctx context.Context, keyword string, page, limit int) (*AnnotationListResponse, error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(limit)))
+	if keyword != "" {
+		query.Set("keyword", keyword)
+	}
+
+	var resp AnnotationListResponse
+	err := c.doRequestWithResponse(ctx, "GET", "/apps/annotations?"+query.Encode(), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateAnnotation 创建标注
+func (c *ChatClient) CreateAnnotation(ctx context.Context, req *AnnotationRequest) (*Annotation, error) {
+	var resp Annotation
+	err := c.doRequestWithResponse(ctx, "POST", "/apps/annotations", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateAnnotation 更新标注
+func (c *ChatClient) UpdateAnnotation(ctx context.Context, annotationID string, req *AnnotationRequest) (*Annotation, error) {
+	var resp Annotation
+	err := c.doRequestWithResponse(ctx, "PUT", fmt.Sprintf("/apps/annotations/%s", annotationID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteAnnotation 删除标注
+func (c *ChatClient) DeleteAnnotation(ctx context.Context, annotationID string) error {
+	return c.doRequestWithResponse(ctx, "DELETE", fmt.Sprintf("/apps/annotations/%s", annotationID), nil, nil)
+}
+
+// EnableAnnotationReply 启用标注回复, 返回异步任务
+func (c *ChatClient) EnableAnnotationReply(ctx context.Context, req *AnnotationReplyRequest) (*AnnotationReplyJob, error) {
+	var resp AnnotationReplyJob
+	err := c.doRequestWithResponse(ctx, "POST", "/apps/annotation-reply/"+AnnotationReplyEnable, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DisableAnnotationReply 禁用标注回复, 返回异步任务
+//
+// Dify 对启用与禁用使用同一接口, 禁用时同样要求提交 embedding 模型与相似度阈值。
+func (c *ChatClient) DisableAnnotationReply(ctx context.Context, req *AnnotationReplyRequest) (*AnnotationReplyJob, error) {
+	var resp AnnotationReplyJob
+	err := c.doRequestWithResponse(ctx, "POST", "/apps/annotation-reply/"+AnnotationReplyDisable, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetAnnotationReplyStatus 查询标注回复任务状态, action 为 enable 或 disable
+func (c *ChatClient) GetAnnotationReplyStatus(ctx context.Context, action, jobID string) (*AnnotationReplyJob, error) {
+	var resp AnnotationReplyJob
+	path := fmt.Sprintf("/apps/annotation-reply/%s/status/%s", action, jobID)
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForAnnotationReply 轮询标注回复任务直到结束
+//
+// 任务失败时返回 *AnnotationJobFailedError; interval 小于等于 0 时使用 1s。
+func (c *ChatClient) WaitForAnnotationReply(ctx context.Context, action, jobID string, interval time.Duration) (*AnnotationReplyJob, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		job, err := c.GetAnnotationReplyStatus(ctx, action, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch job.JobStatus {
+		case AnnotationJobCompleted:
+			return job, nil
+		case AnnotationJobError:
+			return job, &AnnotationJobFailedError{JobID: jobID, Message: job.ErrorMsg}
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return job, err
+		}
+	}
+}
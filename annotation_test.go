@@ -0,0 +1,117 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAnnotationReplyServer 模拟 Dify 的 annotation-reply 接口
+//
+// 与服务端一致, enable 与 disable 都要求 score_threshold、embedding_provider_name 与 embedding_model_name。
+func newAnnotationReplyServer(t *testing.T, statuses ...string) *httptest.Server {
+	t.Helper()
+
+	var polls int
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps/annotation-reply/enable", "/apps/annotation-reply/disable":
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":"invalid_param","message":"body is required","status":400}`))
+				return
+			}
+			for _, key := range []string{"score_threshold", "embedding_provider_name", "embedding_model_name"} {
+				if _, ok := body[key]; !ok {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"code":"invalid_param","message":"` + key + ` is required","status":400}`))
+					return
+				}
+			}
+			w.Write([]byte(`{"job_id":"job-1","job_status":"waiting"}`))
+		case "/apps/annotation-reply/disable/status/job-1":
+			status := statuses[min(polls, len(statuses)-1)]
+			polls++
+			json.NewEncoder(w).Encode(AnnotationReplyJob{JobID: "job-1", JobStatus: status, ErrorMsg: "model unavailable"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newAnnotationTestClient(t *testing.T, baseURL string) *ChatClient {
+	t.Helper()
+	client, err := NewChatClient(ClientConfig{APIKey: "test-key", BaseURL: baseURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestAnnotationReplyActions(t *testing.T) {
+	server := newAnnotationReplyServer(t, AnnotationJobCompleted)
+	defer server.Close()
+
+	client := newAnnotationTestClient(t, server.URL)
+	req := &AnnotationReplyRequest{
+		EmbeddingProviderName: "openai",
+		EmbeddingModelName:    "text-embedding-3-small",
+		ScoreThreshold:        0.9,
+	}
+
+	job, err := client.EnableAnnotationReply(context.Background(), req)
+	if err != nil {
+		t.Fatalf("EnableAnnotationReply() error = %v", err)
+	}
+	if job.JobID != "job-1" {
+		t.Errorf("JobID = %q, want job-1", job.JobID)
+	}
+
+	job, err = client.DisableAnnotationReply(context.Background(), req)
+	if err != nil {
+		t.Fatalf("DisableAnnotationReply() error = %v", err)
+	}
+	if job.JobID != "job-1" {
+		t.Errorf("JobID = %q, want job-1", job.JobID)
+	}
+}
+
+func TestWaitForAnnotationReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		wantErr  bool
+	}{
+		{name: "completed", statuses: []string{AnnotationJobWaiting, AnnotationJobProcessing, AnnotationJobCompleted}},
+		{name: "failed", statuses: []string{AnnotationJobProcessing, AnnotationJobError}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAnnotationReplyServer(t, tt.statuses...)
+			defer server.Close()
+
+			client := newAnnotationTestClient(t, server.URL)
+			job, err := client.WaitForAnnotationReply(context.Background(), AnnotationReplyDisable, "job-1", time.Millisecond)
+
+			var failed *AnnotationJobFailedError
+			if tt.wantErr {
+				if !errors.As(err, &failed) || failed.Message != "model unavailable" {
+					t.Fatalf("error = %v, want *AnnotationJobFailedError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WaitForAnnotationReply() error = %v", err)
+			}
+			if job.JobStatus != AnnotationJobCompleted {
+				t.Errorf("JobStatus = %q, want completed", job.JobStatus)
+			}
+		})
+	}
+}
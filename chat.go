@@ -45,7 +45,7 @@ func (c *ChatClient) SendMessageStream(ctx context.Context, req *ChatRequest) (*
 	if err != nil {
 		return nil, err
 	}
-	return NewStreamReader(resp), nil
+	return c.newStreamReader(resp), nil
 }
 
 // StopMessage 停止响应
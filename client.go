@@ -3,7 +3,6 @@ package dify
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,6 +22,23 @@ type ClientConfig struct {
 	BaseURL string
 	Timeout time.Duration
 	SkipTLS bool
+	Retry   *RetryPolicy // 重试策略, 为 nil 时不重试
+
+	// HTTPClient 自定义 HTTP 客户端, 设置后忽略 Timeout、SkipTLS、Transport 与 TransportConfig
+	HTTPClient *http.Client
+	// Transport 自定义 RoundTripper, 设置后忽略 SkipTLS 与 TransportConfig
+	Transport http.RoundTripper
+	// TransportConfig 内置 Transport 的证书、代理与连接池配置
+	TransportConfig TransportConfig
+
+	// StreamErrorPassthrough 为 true 时流中的 error 事件作为普通事件返回, 不转换为 *StreamError
+	StreamErrorPassthrough bool
+
+	// Middlewares 请求中间件, 按顺序由外到内包装每一次 HTTP 请求
+	Middlewares []Middleware
+
+	// Tracer 分布式追踪, 为 nil 时不创建 span
+	Tracer Tracer
 }
 
 // Client Dify API 客户端
@@ -30,6 +46,11 @@ type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	retry      *RetryPolicy
+	roundTrip  RoundTripFunc
+	tracer     Tracer
+
+	streamErrorPassthrough bool
 }
 
 // NewClient 创建新的 Dify 客户端
@@ -45,39 +66,90 @@ func NewClient(config ClientConfig) (*Client, error) {
 	}
 	baseURL = strings.TrimSuffix(baseURL, "/")
 
-	timeout := config.Timeout
-	if timeout <= 0 {
-		timeout = DefaultTimeout
-	}
-
-	transport := &http.Transport{}
-	if config.SkipTLS {
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-
-	httpClient := &http.Client{
-		Timeout:   timeout,
-		Transport: transport,
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
 		apiKey:     apiKey,
 		baseURL:    baseURL,
 		httpClient: httpClient,
+		retry:      config.Retry.normalize(),
+		roundTrip:  chainMiddlewares(httpClient.Do, config.Middlewares),
+		tracer:     config.Tracer,
+
+		streamErrorPassthrough: config.StreamErrorPassthrough,
 	}, nil
 }
 
 // doRequest 执行 HTTP 请求
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
-	url := c.baseURL + path
-
-	var bodyReader io.Reader
+	var payload []byte
 	if body != nil {
 		jsonData, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonData)
+		payload = jsonData
+	}
+
+	return c.do(ctx, method, path, payload, "application/json")
+}
+
+// do 发送请求, 配置了 Tracer 时为整个调用创建 span
+func (c *Client) do(ctx context.Context, method, path string, payload []byte, contentType string) (*http.Response, error) {
+	send := func(ctx context.Context) (*http.Response, int, error) {
+		return c.sendWithRetry(ctx, method, path, payload, contentType)
+	}
+	if c.tracer != nil {
+		return c.traceRequest(ctx, method, path, send)
+	}
+
+	resp, _, err := send(ctx)
+	return resp, err
+}
+
+// sendWithRetry 按重试策略重放请求体, 返回实际尝试次数
+func (c *Client) sendWithRetry(ctx context.Context, method, path string, payload []byte, contentType string) (*http.Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, path, payload, contentType)
+
+		if c.retry == nil || attempt >= c.retry.MaxAttempts {
+			return resp, attempt, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		if err != nil {
+			if !c.retry.shouldRetryError(ctx, method, err) {
+				return nil, attempt, err
+			}
+		} else {
+			if !c.retry.shouldRetryResponse(resp) {
+				return resp, attempt, nil
+			}
+			if retryAfter, ok := parseRetryAfter(resp); ok {
+				if retryAfter > c.retry.MaxBackoff {
+					return resp, attempt, nil
+				}
+				delay = retryAfter
+			}
+			discardResponse(resp)
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, attempt, fmt.Errorf("failed to execute request: %w", err)
+		}
+	}
+}
+
+// send 执行单次 HTTP 请求
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, contentType string) (*http.Response, error) {
+	url := c.baseURL + path
+
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
@@ -86,9 +158,12 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	if c.tracer != nil {
+		c.tracer.Inject(ctx, req.Header)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.roundTrip(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -102,6 +177,11 @@ func (c *Client) doRequestWithResponse(ctx context.Context, method, path string,
 	if err != nil {
 		return err
 	}
+	return decodeResponse(resp, result)
+}
+
+// decodeResponse 读取并关闭响应体, 解析错误或结果
+func decodeResponse(resp *http.Response, result interface{}) error {
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -110,7 +190,7 @@ func (c *Client) doRequestWithResponse(ctx context.Context, method, path string,
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return ParseAPIError(resp.StatusCode, respBody)
+		return parseResponseError(resp, respBody)
 	}
 
 	if result != nil {
@@ -132,16 +212,29 @@ func (c *Client) doStreamRequest(ctx context.Context, method, path string, body
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		defer resp.Body.Close()
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, ParseAPIError(resp.StatusCode, respBody)
+		return nil, parseResponseError(resp, respBody)
 	}
 
 	return resp, nil
 }
 
+// newStreamReader 按客户端配置创建流式读取器
+func (c *Client) newStreamReader(resp *http.Response) *StreamReader {
+	sr := NewStreamReader(resp)
+	sr.errorPassthrough = c.streamErrorPassthrough
+	if c.tracer != nil && resp.Request != nil {
+		sr.nodes = newNodeTracer(c.tracer, resp.Request.Context())
+	}
+	return sr
+}
+
 // StreamReader 流式响应读取器
 type StreamReader struct {
 	response *http.Response
 	reader   *SSEReader
+
+	errorPassthrough bool
+	nodes            *nodeTracer // 为 nil 时不追踪节点
 }
 
 // NewStreamReader 创建流式读取器
@@ -152,113 +245,46 @@ func NewStreamReader(resp *http.Response) *StreamReader {
 	}
 }
 
-// Read 读取下一个事件
-func (sr *StreamReader) Read() (*SSEMessage, error) {
-	return sr.reader.Read()
-}
-
-// Close 关闭流
-func (sr *StreamReader) Close() error {
-	return sr.response.Body.Close()
+// SetErrorPassthrough 设置是否将 error 事件作为普通事件返回
+func (sr *StreamReader) SetErrorPassthrough(passthrough bool) {
+	sr.errorPassthrough = passthrough
 }
 
-// SSEReader SSE 事件读取器
-type SSEReader struct {
-	reader io.Reader
-	buffer []byte
-}
-
-// SSEMessage SSE 消息
-type SSEMessage struct {
-	Event string
-	Data  string
-}
-
-// NewSSEReader 创建 SSE 读取器
-func NewSSEReader(reader io.Reader) *SSEReader {
-	return &SSEReader{
-		reader: reader,
-		buffer: make([]byte, 0),
+// Read 读取下一个事件
+//
+// 收到 error 事件时返回 *StreamError, 除非启用了 error 事件透传。
+func (sr *StreamReader) Read() (*SSEMessage, error) {
+	msg, err := sr.reader.Read()
+	if err != nil {
+		return nil, err
 	}
-}
-
-// Read 读取下一个 SSE 事件
-func (r *SSEReader) Read() (*SSEMessage, error) {
-	buf := make([]byte, 4096)
-
-	for {
-		// 检查缓冲区中是否有完整的消息 (以 \n\n 或 \n 结尾的 data: 行)
-		if idx := bytes.Index(r.buffer, []byte("\n\n")); idx >= 0 {
-			msgData := r.buffer[:idx]
-			r.buffer = r.buffer[idx+2:]
-
-			msg := r.parseSSEData(msgData)
-			if msg != nil {
-				return msg, nil
-			}
-			continue
-		}
-
-		// 也检查单个换行符分隔的情况
-		if idx := bytes.Index(r.buffer, []byte("\n")); idx >= 0 {
-			line := r.buffer[:idx]
-			if bytes.HasPrefix(line, []byte("data:")) {
-				r.buffer = r.buffer[idx+1:]
-				msg := r.parseSSEData(line)
-				if msg != nil {
-					return msg, nil
-				}
-				continue
-			}
-		}
-
-		// 读取更多数据
-		n, err := r.reader.Read(buf)
-		if n > 0 {
-			r.buffer = append(r.buffer, buf[:n]...)
-		}
-		if err != nil {
-			if err == io.EOF {
-				// 处理剩余数据
-				if len(r.buffer) > 0 {
-					msg := r.parseSSEData(r.buffer)
-					r.buffer = nil
-					if msg != nil {
-						return msg, nil
-					}
-				}
-				return nil, io.EOF
-			}
-			return nil, err
-		}
+	if sr.nodes != nil {
+		sr.nodes.observe(msg)
 	}
-}
 
-// parseSSEData 解析 SSE 数据行
-func (r *SSEReader) parseSSEData(data []byte) *SSEMessage {
-	msg := &SSEMessage{}
-
-	lines := bytes.Split(data, []byte("\n"))
-	for _, line := range lines {
-		if bytes.HasPrefix(line, []byte("event:")) {
-			msg.Event = strings.TrimSpace(string(line[6:]))
-		} else if bytes.HasPrefix(line, []byte("data:")) {
-			msg.Data = strings.TrimSpace(string(line[5:]))
+	if msg.Event == EventError && !sr.errorPassthrough {
+		var e ErrorStreamEvent
+		if err := json.Unmarshal([]byte(msg.Data), &e); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal error event: %w", err)
 		}
+		return nil, newStreamError(&e)
 	}
+	return msg, nil
+}
 
-	// 如果没有 event: 行，尝试从 data JSON 中提取 event 字段
-	if msg.Event == "" && msg.Data != "" {
-		var eventData struct {
-			Event string `json:"event"`
-		}
-		if err := json.Unmarshal([]byte(msg.Data), &eventData); err == nil && eventData.Event != "" {
-			msg.Event = eventData.Event
-		}
+// Next 读取并解码下一个事件
+func (sr *StreamReader) Next() (Event, error) {
+	msg, err := sr.Read()
+	if err != nil {
+		return nil, err
 	}
+	return DecodeEvent(msg)
+}
 
-	if msg.Event != "" || msg.Data != "" {
-		return msg
+// Close 关闭流
+func (sr *StreamReader) Close() error {
+	if sr.nodes != nil {
+		sr.nodes.close()
 	}
-	return nil
+	return sr.response.Body.Close()
 }
@@ -3,80 +3,24 @@ package dify
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 // UploadFile 上传文件
 func (c *Client) UploadFile(ctx context.Context, filePath string, user string) (*FileUploadResponse, error) {
-	if user == "" {
-		user = DefaultUser
-	}
-
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
-	}
-
-	err = writer.WriteField("user", user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write user field: %w", err)
-	}
-
-	err = writer.Close()
-	if err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	url := c.baseURL + "/files/upload"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, ParseAPIError(resp.StatusCode, respBody)
-	}
-
-	var result FileUploadResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	return &result, nil
+	return c.UploadFileFromReader(ctx, file, filepath.Base(filePath), user)
 }
 
 // UploadFileFromReader 从 Reader 上传文件
@@ -85,59 +29,49 @@ func (c *Client) UploadFileFromReader(ctx context.Context, reader io.Reader, fil
 		user = DefaultUser
 	}
 
+	var result FileUploadResponse
+	fields := map[string]string{"user": user}
+	err := c.doMultipartRequest(ctx, "/files/upload", filename, reader, fields, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// doMultipartRequest 以 multipart/form-data 上传文件并解析响应
+//
+// 请求体会先完整写入内存, 以便中间件和重试策略可以重放请求。
+func (c *Client) doMultipartRequest(ctx context.Context, path string, filename string, reader io.Reader, fields map[string]string, result interface{}) error {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
 	part, err := writer.CreateFormFile("file", filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
+		return fmt.Errorf("failed to create form file: %w", err)
 	}
 
 	_, err = io.Copy(part, reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
+		return fmt.Errorf("failed to copy file content: %w", err)
 	}
 
-	err = writer.WriteField("user", user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write user field: %w", err)
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		err = writer.WriteField(key, fields[key])
+		if err != nil {
+			return fmt.Errorf("failed to write %s field: %w", key, err)
+		}
 	}
 
 	err = writer.Close()
 	if err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	url := c.baseURL + "/files/upload"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return fmt.Errorf("failed to close multipart writer: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := c.do(ctx, "POST", path, body.Bytes(), writer.FormDataContentType())
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, ParseAPIError(resp.StatusCode, respBody)
-	}
-
-	var result FileUploadResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return err
 	}
-
-	return &result, nil
+	return decodeResponse(resp, result)
 }
 
 // TextToAudio 文字转语音
@@ -160,7 +94,7 @@ func (c *Client) TextToAudio(ctx context.Context, text string, user string, stre
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		defer resp.Body.Close()
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, ParseAPIError(resp.StatusCode, respBody)
+		return nil, parseResponseError(resp, respBody)
 	}
 
 	return resp.Body, nil
@@ -178,57 +112,11 @@ func (c *Client) AudioToText(ctx context.Context, audioFilePath string, user str
 	}
 	defer file.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	part, err := writer.CreateFormFile("file", filepath.Base(audioFilePath))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to copy file content: %w", err)
-	}
-
-	err = writer.WriteField("user", user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to write user field: %w", err)
-	}
-
-	err = writer.Close()
-	if err != nil {
-		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
-	}
-
-	url := c.baseURL + "/audio-to-text"
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, ParseAPIError(resp.StatusCode, respBody)
-	}
-
 	var result AudioToTextResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	fields := map[string]string{"user": user}
+	err = c.doMultipartRequest(ctx, "/audio-to-text", filepath.Base(audioFilePath), file, fields, &result)
+	if err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
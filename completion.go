@@ -45,7 +45,7 @@ func (c *CompletionClient) SendMessageStream(ctx context.Context, req *Completio
 	if err != nil {
 		return nil, err
 	}
-	return NewStreamReader(resp), nil
+	return c.newStreamReader(resp), nil
 }
 
 // StopMessage 停止响应
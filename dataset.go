@@ -0,0 +1,92 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// DatasetClient 知识库客户端, 需使用知识库 API Key
+type DatasetClient struct {
+	*Client
+}
+
+// NewDatasetClient 创建知识库客户端
+func NewDatasetClient(config ClientConfig) (*DatasetClient, error) {
+	client, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &DatasetClient{Client: client}, nil
+}
+
+// CreateDataset 创建空知识库
+func (c *DatasetClient) CreateDataset(ctx context.Context, req *CreateDatasetRequest) (*Dataset, error) {
+	var resp Dataset
+	err := c.doRequestWithResponse(ctx, "POST", "/datasets", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListDatasets 获取知识库列表, opts 可为 nil
+func (c *DatasetClient) ListDatasets(ctx context.Context, opts *DatasetListOptions) (*DatasetListResponse, error) {
+	if opts == nil {
+		opts = &DatasetListOptions{}
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(opts.Page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(opts.Limit)))
+	if opts.Keyword != "" {
+		query.Set("keyword", opts.Keyword)
+	}
+	for _, tagID := range opts.TagIDs {
+		query.Add("tag_ids", tagID)
+	}
+	if opts.IncludeAll {
+		query.Set("include_all", "true")
+	}
+
+	var resp DatasetListResponse
+	err := c.doRequestWithResponse(ctx, "GET", "/datasets?"+query.Encode(), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetDataset 获取知识库详情
+func (c *DatasetClient) GetDataset(ctx context.Context, datasetID string) (*Dataset, error) {
+	var resp Dataset
+	err := c.doRequestWithResponse(ctx, "GET", fmt.Sprintf("/datasets/%s", datasetID), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateDataset 更新知识库
+func (c *DatasetClient) UpdateDataset(ctx context.Context, datasetID string, req *UpdateDatasetRequest) (*Dataset, error) {
+	var resp Dataset
+	err := c.doRequestWithResponse(ctx, "PATCH", fmt.Sprintf("/datasets/%s", datasetID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteDataset 删除知识库
+func (c *DatasetClient) DeleteDataset(ctx context.Context, datasetID string) error {
+	return c.doRequestWithResponse(ctx, "DELETE", fmt.Sprintf("/datasets/%s", datasetID), nil, nil)
+}
+
+// defaultLimit 分页大小默认值
+func defaultLimit(limit int) int {
+	if limit <= 0 {
+		return 20
+	}
+	return limit
+}
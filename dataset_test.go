@@ -0,0 +1,104 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// newDatasetTestClient 创建指向测试服务器的知识库客户端
+func newDatasetTestClient(t *testing.T, handler http.HandlerFunc) *DatasetClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewDatasetClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestListDatasetsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *DatasetListOptions
+		want url.Values
+	}{
+		{
+			name: "defaults",
+			opts: nil,
+			want: url.Values{"page": {"1"}, "limit": {"20"}},
+		},
+		{
+			name: "tag ids repeated",
+			opts: &DatasetListOptions{Keyword: "产品 手册", TagIDs: []string{"tag-1", "tag-2"}, IncludeAll: true, Page: 3, Limit: 50},
+			want: url.Values{
+				"page":        {"3"},
+				"limit":       {"50"},
+				"keyword":     {"产品 手册"},
+				"tag_ids":     {"tag-1", "tag-2"},
+				"include_all": {"true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got url.Values
+			client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/datasets" {
+					http.Error(w, "unexpected request", http.StatusTeapot)
+					return
+				}
+				got = r.URL.Query()
+				w.Write([]byte(`{"data":[{"id":"ds","name":"docs"}],"has_more":false,"limit":20,"total":1,"page":1}`))
+			})
+
+			resp, err := client.ListDatasets(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("ListDatasets() error = %v", err)
+			}
+			if len(resp.Data) != 1 || resp.Data[0].ID != "ds" {
+				t.Errorf("Data = %+v", resp.Data)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateDataset(t *testing.T) {
+	var body map[string]interface{}
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/datasets" {
+			http.Error(w, "unexpected request", http.StatusTeapot)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"id":"ds","name":"docs","indexing_technique":"high_quality"}`))
+	})
+
+	dataset, err := client.CreateDataset(context.Background(), &CreateDatasetRequest{
+		Name:              "docs",
+		IndexingTechnique: IndexingTechniqueHighQuality,
+	})
+	if err != nil {
+		t.Fatalf("CreateDataset() error = %v", err)
+	}
+	if dataset.ID != "ds" {
+		t.Errorf("ID = %q, want ds", dataset.ID)
+	}
+
+	// 未设置的可选字段不发送
+	want := map[string]interface{}{"name": "docs", "indexing_technique": "high_quality"}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+}
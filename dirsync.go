@@ -0,0 +1,433 @@
+package dify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultSyncManifestName = ".dify-sync.json"
+	DefaultSyncConcurrency  = 4
+)
+
+// 同步操作类型
+const (
+	SyncOpCreate    = "create"
+	SyncOpUpdate    = "update"
+	SyncOpDelete    = "delete"
+	SyncOpUnchanged = "unchanged"
+)
+
+// SyncOptions 目录同步参数
+type SyncOptions struct {
+	DatasetID    string
+	Dir          string
+	ManifestPath string   // 清单文件路径, 默认为 Dir/.dify-sync.json
+	Include      []string // 包含的 glob, 为空时包含全部文件
+	Exclude      []string // 排除的 glob
+	DryRun       bool     // 仅输出计划, 不调用 API 也不写清单
+	Concurrency  int      // 并发上传数 (默认 4)
+
+	// Document 新建文档时使用的参数, 为 nil 时使用自动分段与高质量索引
+	Document *CreateDocumentByFileRequest
+
+	// OnAction 每个操作完成 (或 DryRun 时计划) 后回调, 因 ctx 取消而未执行的操作也会回调, 其 Err 为 ctx.Err()
+	OnAction func(action SyncAction)
+}
+
+// SyncAction 单个文件的同步操作
+type SyncAction struct {
+	Op         string
+	Path       string // 相对 Dir 的路径, 使用 / 分隔
+	DocumentID string
+	Batch      string
+	Err        error
+}
+
+// SyncResult 同步结果
+type SyncResult struct {
+	Actions []SyncAction
+}
+
+// Count 统计指定操作类型的成功数量
+func (r *SyncResult) Count(op string) int {
+	n := 0
+	for _, action := range r.Actions {
+		if action.Op == op && action.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// SyncManifest 本地文件与知识库文档的对应关系
+type SyncManifest struct {
+	DatasetID string                       `json:"dataset_id"`
+	Files     map[string]SyncManifestEntry `json:"files"`
+}
+
+// SyncManifestEntry 清单中的单个文件
+type SyncManifestEntry struct {
+	Hash       string `json:"hash"`
+	DocumentID string `json:"document_id"`
+}
+
+// SyncDirectory 将本地目录同步到知识库
+//
+// 按文件 SHA-256 判断变更: 新文件创建文档, 内容变化的文件更新文档,
+// 已删除的文件删除对应文档。单个文件失败不会中断同步, 所有错误合并后返回,
+// 成功的操作仍会写入清单。
+func (c *DatasetClient) SyncDirectory(ctx context.Context, opts SyncOptions) (*SyncResult, error) {
+	if opts.DatasetID == "" {
+		return nil, fmt.Errorf("dataset id is required")
+	}
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("dir is required")
+	}
+
+	manifestPath := opts.ManifestPath
+	if manifestPath == "" {
+		manifestPath = filepath.Join(opts.Dir, DefaultSyncManifestName)
+	}
+
+	manifest, err := LoadSyncManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.DatasetID != "" && manifest.DatasetID != opts.DatasetID {
+		return nil, fmt.Errorf("manifest belongs to dataset %s", manifest.DatasetID)
+	}
+	manifest.DatasetID = opts.DatasetID
+
+	files, err := scanSyncDir(opts.Dir, manifestPath, opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := planSync(manifest, files)
+	result := &SyncResult{Actions: actions}
+
+	if opts.DryRun {
+		for _, action := range actions {
+			if opts.OnAction != nil {
+				opts.OnAction(action)
+			}
+		}
+		return result, nil
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultSyncConcurrency
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i := range result.Actions {
+		action := &result.Actions[i]
+		if action.Op == SyncOpUnchanged {
+			if opts.OnAction != nil {
+				opts.OnAction(*action)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				defer mu.Unlock()
+				action.Err = ctx.Err()
+				if opts.OnAction != nil {
+					opts.OnAction(*action)
+				}
+				return
+			}
+
+			c.applySyncAction(ctx, opts, action)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if action.Err == nil {
+				if action.Op == SyncOpDelete {
+					delete(manifest.Files, action.Path)
+				} else {
+					manifest.Files[action.Path] = SyncManifestEntry{
+						Hash:       files[action.Path],
+						DocumentID: action.DocumentID,
+					}
+				}
+			}
+			if opts.OnAction != nil {
+				opts.OnAction(*action)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, action := range result.Actions {
+		if action.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", action.Op, action.Path, action.Err))
+		}
+	}
+	if err := manifest.Save(manifestPath); err != nil {
+		errs = append(errs, err)
+	}
+	return result, errors.Join(errs...)
+}
+
+// applySyncAction 执行单个同步操作
+func (c *DatasetClient) applySyncAction(ctx context.Context, opts SyncOptions, action *SyncAction) {
+	localPath := filepath.Join(opts.Dir, filepath.FromSlash(action.Path))
+
+	switch action.Op {
+	case SyncOpCreate:
+		action.DocumentID, action.Batch, action.Err = c.createSyncDocument(ctx, opts, localPath)
+	case SyncOpUpdate:
+		req := &UpdateDocumentByFileRequest{Name: path.Base(action.Path)}
+		if opts.Document != nil {
+			req.DocForm = opts.Document.DocForm
+			req.ProcessRule = opts.Document.ProcessRule
+		}
+		resp, err := c.UpdateDocumentByFile(ctx, opts.DatasetID, action.DocumentID, localPath, req)
+		if errors.Is(err, ErrNotFound) {
+			// 文档已在远端被删除, 重新创建
+			action.Op = SyncOpCreate
+			action.DocumentID, action.Batch, action.Err = c.createSyncDocument(ctx, opts, localPath)
+			return
+		}
+		if err != nil {
+			action.Err = err
+			return
+		}
+		action.Batch = resp.Batch
+	case SyncOpDelete:
+		err := c.DeleteDocument(ctx, opts.DatasetID, action.DocumentID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			action.Err = err
+		}
+	}
+}
+
+// createSyncDocument 通过文件创建文档
+func (c *DatasetClient) createSyncDocument(ctx context.Context, opts SyncOptions, localPath string) (string, string, error) {
+	req := opts.Document
+	if req == nil {
+		req = &CreateDocumentByFileRequest{
+			IndexingTechnique: IndexingTechniqueHighQuality,
+			ProcessRule:       &ProcessRule{Mode: ProcessModeAutomatic},
+		}
+	}
+
+	resp, err := c.CreateDocumentByFile(ctx, opts.DatasetID, localPath, req)
+	if err != nil {
+		return "", "", err
+	}
+	return resp.Document.ID, resp.Batch, nil
+}
+
+// planSync 对比清单与本地文件生成同步计划
+func planSync(manifest *SyncManifest, files map[string]string) []SyncAction {
+	var actions []SyncAction
+	for rel, hash := range files {
+		entry, ok := manifest.Files[rel]
+		switch {
+		case !ok:
+			actions = append(actions, SyncAction{Op: SyncOpCreate, Path: rel})
+		case entry.Hash != hash:
+			actions = append(actions, SyncAction{Op: SyncOpUpdate, Path: rel, DocumentID: entry.DocumentID})
+		default:
+			actions = append(actions, SyncAction{Op: SyncOpUnchanged, Path: rel, DocumentID: entry.DocumentID})
+		}
+	}
+	for rel, entry := range manifest.Files {
+		if _, ok := files[rel]; !ok {
+			actions = append(actions, SyncAction{Op: SyncOpDelete, Path: rel, DocumentID: entry.DocumentID})
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Path < actions[j].Path
+	})
+	return actions
+}
+
+// scanSyncDir 遍历目录并计算文件哈希, 返回 相对路径 -> 哈希
+func scanSyncDir(dir, manifestPath string, include, exclude []string) (map[string]string, error) {
+	includeRes, err := compileGlobs(include)
+	if err != nil {
+		return nil, err
+	}
+	excludeRes, err := compileGlobs(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestAbs, _ := filepath.Abs(manifestPath)
+	files := make(map[string]string)
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && matchGlobs(excludeRes, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if abs, _ := filepath.Abs(p); abs == manifestAbs {
+			return nil
+		}
+		if len(includeRes) > 0 && !matchGlobs(includeRes, rel) {
+			return nil
+		}
+		if matchGlobs(excludeRes, rel) {
+			return nil
+		}
+
+		hash, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		files[rel] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directory: %w", err)
+	}
+	return files, nil
+}
+
+// hashFile 计算文件 SHA-256
+func hashFile(p string) (string, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// compileGlobs 将 glob 转换为正则表达式
+//
+// 支持 *、? 与 **; 不含 / 的模式匹配任意层级的文件名。
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+
+		var sb strings.Builder
+		sb.WriteString("^")
+		for i := 0; i < len(pattern); i++ {
+			switch ch := pattern[i]; {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				sb.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				sb.WriteString(".*")
+				i++
+			case ch == '*':
+				sb.WriteString("[^/]*")
+			case ch == '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(ch)))
+			}
+		}
+		sb.WriteString("$")
+
+		re, err := regexp.Compile(sb.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchGlobs 判断路径是否匹配任一模式
+func matchGlobs(res []*regexp.Regexp, rel string) bool {
+	for _, re := range res {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSyncManifest 读取同步清单, 文件不存在时返回空清单
+func LoadSyncManifest(p string) (*SyncManifest, error) {
+	manifest := &SyncManifest{Files: make(map[string]SyncManifestEntry)}
+
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]SyncManifestEntry)
+	}
+	return manifest, nil
+}
+
+// Save 写入同步清单
+func (m *SyncManifest) Save(p string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCompileGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "*.md", path: "README.md", want: true},
+		{pattern: "*.md", path: "docs/guide/intro.md", want: true},
+		{pattern: "*.md", path: "README.txt", want: false},
+		{pattern: "*.md", path: "notes.md.bak", want: false},
+		{pattern: "docs/*.md", path: "docs/intro.md", want: true},
+		{pattern: "docs/*.md", path: "docs/guide/intro.md", want: false},
+		{pattern: "docs/**", path: "docs/intro.md", want: true},
+		{pattern: "docs/**", path: "docs/guide/intro.md", want: true},
+		{pattern: "docs/**", path: "docs", want: false},
+		{pattern: "docs/**", path: "other/docs/intro.md", want: false},
+		{pattern: "docs/**/*.md", path: "docs/intro.md", want: true},
+		{pattern: "docs/**/*.md", path: "docs/a/b/intro.md", want: true},
+		{pattern: "/docs/*.md", path: "docs/intro.md", want: true},
+		{pattern: "node_modules", path: "node_modules", want: true},
+		{pattern: "node_modules", path: "web/node_modules", want: true},
+		{pattern: "node_modules", path: "node_modules_backup", want: false},
+		{pattern: "?.txt", path: "a.txt", want: true},
+		{pattern: "?.txt", path: "ab.txt", want: false},
+		{pattern: "?.txt", path: "/.txt", want: false},
+		{pattern: "file[1].txt", path: "file[1].txt", want: true},
+		{pattern: "file[1].txt", path: "file1.txt", want: false},
+		{pattern: "a+b.md", path: "a+b.md", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			res, err := compileGlobs([]string{tt.pattern})
+			if err != nil {
+				t.Fatalf("compileGlobs() error = %v", err)
+			}
+			if got := matchGlobs(res, tt.path); got != tt.want {
+				t.Errorf("match(%q, %q) = %v, want %v (regexp %s)", tt.pattern, tt.path, got, tt.want, res[0])
+			}
+		})
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	manifest := &SyncManifest{
+		DatasetID: "ds",
+		Files: map[string]SyncManifestEntry{
+			"same.md":    {Hash: "h1", DocumentID: "doc-same"},
+			"changed.md": {Hash: "old", DocumentID: "doc-changed"},
+			"removed.md": {Hash: "h3", DocumentID: "doc-removed"},
+		},
+	}
+	files := map[string]string{
+		"same.md":    "h1",
+		"changed.md": "new",
+		"added.md":   "h4",
+	}
+
+	got := planSync(manifest, files)
+	want := []SyncAction{
+		{Op: SyncOpCreate, Path: "added.md"},
+		{Op: SyncOpUpdate, Path: "changed.md", DocumentID: "doc-changed"},
+		{Op: SyncOpDelete, Path: "removed.md", DocumentID: "doc-removed"},
+		{Op: SyncOpUnchanged, Path: "same.md", DocumentID: "doc-same"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("planSync() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanSyncEmpty(t *testing.T) {
+	manifest := &SyncManifest{Files: map[string]SyncManifestEntry{}}
+	if got := planSync(manifest, map[string]string{}); len(got) != 0 {
+		t.Errorf("planSync() = %+v, want no actions", got)
+	}
+}
+
+// writeTestFiles 在 dir 下创建文件, 键为 / 分隔的相对路径
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanSyncDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"README.md":                  "readme",
+		"notes.txt":                  "notes",
+		"docs/intro.md":              "intro",
+		"docs/draft/wip.md":          "wip",
+		"node_modules/pkg/index.md":  "vendored",
+		DefaultSyncManifestName:      "{}",
+		"state/custom-manifest.json": "{}",
+	})
+
+	tests := []struct {
+		name     string
+		manifest string
+		include  []string
+		exclude  []string
+		want     []string
+	}{
+		{
+			name:     "all files except manifest",
+			manifest: filepath.Join(dir, DefaultSyncManifestName),
+			want:     []string{"README.md", "docs/draft/wip.md", "docs/intro.md", "node_modules/pkg/index.md", "notes.txt", "state/custom-manifest.json"},
+		},
+		{
+			name:     "custom manifest path",
+			manifest: filepath.Join(dir, "state", "custom-manifest.json"),
+			include:  []string{"*.json"},
+			want:     []string{DefaultSyncManifestName},
+		},
+		{
+			name:     "include markdown",
+			manifest: filepath.Join(dir, DefaultSyncManifestName),
+			include:  []string{"*.md"},
+			want:     []string{"README.md", "docs/draft/wip.md", "docs/intro.md", "node_modules/pkg/index.md"},
+		},
+		{
+			name:     "exclude directories",
+			manifest: filepath.Join(dir, DefaultSyncManifestName),
+			include:  []string{"*.md"},
+			exclude:  []string{"node_modules", "docs/draft/**"},
+			want:     []string{"README.md", "docs/intro.md"},
+		},
+		{
+			name:     "include subtree",
+			manifest: filepath.Join(dir, DefaultSyncManifestName),
+			include:  []string{"docs/**"},
+			want:     []string{"docs/draft/wip.md", "docs/intro.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := scanSyncDir(dir, tt.manifest, tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("scanSyncDir() error = %v", err)
+			}
+
+			got := slices.Sorted(maps.Keys(files))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"new.md":     "new",
+		"changed.md": "changed",
+		"gone.md":    "recreated",
+		"same.md":    "same",
+	})
+
+	sameHash, err := hashFile(filepath.Join(dir, "same.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestPath := filepath.Join(dir, DefaultSyncManifestName)
+	manifest := &SyncManifest{
+		DatasetID: "ds",
+		Files: map[string]SyncManifestEntry{
+			"changed.md": {Hash: "old", DocumentID: "doc-changed"},
+			"gone.md":    {Hash: "old", DocumentID: "doc-gone"},
+			"same.md":    {Hash: sameHash, DocumentID: "doc-same"},
+			"removed.md": {Hash: "old", DocumentID: "doc-removed"},
+		},
+	}
+	if err := manifest.Save(manifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/datasets/ds/document/create-by-file":
+			_, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			name := strings.TrimSuffix(header.Filename, ".md")
+			fmt.Fprintf(w, `{"document":{"id":"doc-%s-v2"},"batch":"batch-%s"}`, name, name)
+		case r.Method == http.MethodPost && r.URL.Path == "/datasets/ds/documents/doc-changed/update-by-file":
+			w.Write([]byte(`{"document":{"id":"doc-changed"},"batch":"batch-changed"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/datasets/ds/documents/doc-gone/update-by-file":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not_found","message":"Document not found.","status":404}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/datasets/ds/documents/doc-removed":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "unexpected request", http.StatusTeapot)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewDatasetClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.SyncDirectory(context.Background(), SyncOptions{DatasetID: "ds", Dir: dir})
+	if err != nil {
+		t.Fatalf("SyncDirectory() error = %v (requests %v)", err, requests)
+	}
+
+	want := []SyncAction{
+		{Op: SyncOpUpdate, Path: "changed.md", DocumentID: "doc-changed", Batch: "batch-changed"},
+		{Op: SyncOpCreate, Path: "gone.md", DocumentID: "doc-gone-v2", Batch: "batch-gone"},
+		{Op: SyncOpCreate, Path: "new.md", DocumentID: "doc-new-v2", Batch: "batch-new"},
+		{Op: SyncOpDelete, Path: "removed.md", DocumentID: "doc-removed"},
+		{Op: SyncOpUnchanged, Path: "same.md", DocumentID: "doc-same"},
+	}
+	if !reflect.DeepEqual(result.Actions, want) {
+		t.Errorf("actions = %+v, want %+v", result.Actions, want)
+	}
+
+	saved, err := LoadSyncManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotDocs := make(map[string]string)
+	for rel, entry := range saved.Files {
+		gotDocs[rel] = entry.DocumentID
+	}
+	wantDocs := map[string]string{
+		"changed.md": "doc-changed",
+		"gone.md":    "doc-gone-v2",
+		"new.md":     "doc-new-v2",
+		"same.md":    "doc-same",
+	}
+	if !reflect.DeepEqual(gotDocs, wantDocs) {
+		t.Errorf("manifest documents = %v, want %v", gotDocs, wantDocs)
+	}
+
+	// 再次同步时没有任何变更
+	requests = nil
+	result, err = client.SyncDirectory(context.Background(), SyncOptions{DatasetID: "ds", Dir: dir})
+	if err != nil {
+		t.Fatalf("second SyncDirectory() error = %v", err)
+	}
+	if n := result.Count(SyncOpUnchanged); n != 4 || len(requests) != 0 {
+		t.Errorf("second sync unchanged = %d, requests = %v, want 4 unchanged and no requests", n, requests)
+	}
+}
+
+func TestSyncDirectoryDryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.md": "a"})
+
+	client, err := NewDatasetClient(ClientConfig{APIKey: "test-key", BaseURL: "http://dify.invalid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.SyncDirectory(context.Background(), SyncOptions{DatasetID: "ds", Dir: dir, DryRun: true})
+	if err != nil {
+		t.Fatalf("SyncDirectory() error = %v", err)
+	}
+	if result.Count(SyncOpCreate) != 1 {
+		t.Errorf("actions = %+v, want one create", result.Actions)
+	}
+	if _, err := os.Stat(filepath.Join(dir, DefaultSyncManifestName)); !os.IsNotExist(err) {
+		t.Errorf("manifest written in dry run: %v", err)
+	}
+}
+
+func TestSyncDirectoryCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{"a.md": "a", "b.md": "b", "c.md": "c"})
+
+	client, err := NewDatasetClient(ClientConfig{APIKey: "test-key", BaseURL: "http://dify.invalid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var (
+		mu       sync.Mutex
+		reported []SyncAction
+	)
+	_, err = client.SyncDirectory(ctx, SyncOptions{
+		DatasetID:   "ds",
+		Dir:         dir,
+		Concurrency: 1,
+		OnAction: func(action SyncAction) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, action)
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SyncDirectory() error = %v, want context.Canceled", err)
+	}
+
+	// 取消后未执行的操作同样回调
+	if len(reported) != 3 {
+		t.Fatalf("OnAction calls = %d, want 3", len(reported))
+	}
+	for _, action := range reported {
+		if !errors.Is(action.Err, context.Canceled) {
+			t.Errorf("action %s error = %v, want context.Canceled", action.Path, action.Err)
+		}
+	}
+}
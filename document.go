@@ -0,0 +1,129 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// CreateDocumentByText 通过文本创建文档
+func (c *DatasetClient) CreateDocumentByText(ctx context.Context, datasetID string, req *CreateDocumentByTextRequest) (*DocumentResponse, error) {
+	var resp DocumentResponse
+	err := c.doRequestWithResponse(ctx, "POST", fmt.Sprintf("/datasets/%s/document/create-by-text", datasetID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateDocumentByFile 通过文件创建文档
+func (c *DatasetClient) CreateDocumentByFile(ctx context.Context, datasetID string, filePath string, req *CreateDocumentByFileRequest) (*DocumentResponse, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return c.CreateDocumentByFileFromReader(ctx, datasetID, file, filepath.Base(filePath), req)
+}
+
+// CreateDocumentByFileFromReader 从 Reader 上传文件创建文档
+func (c *DatasetClient) CreateDocumentByFileFromReader(ctx context.Context, datasetID string, reader io.Reader, filename string, req *CreateDocumentByFileRequest) (*DocumentResponse, error) {
+	if req == nil {
+		req = &CreateDocumentByFileRequest{}
+	}
+	path := fmt.Sprintf("/datasets/%s/document/create-by-file", datasetID)
+	return c.uploadDocument(ctx, path, reader, filename, req)
+}
+
+// UpdateDocumentByText 通过文本更新文档
+func (c *DatasetClient) UpdateDocumentByText(ctx context.Context, datasetID, documentID string, req *UpdateDocumentByTextRequest) (*DocumentResponse, error) {
+	var resp DocumentResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/update-by-text", datasetID, documentID)
+	err := c.doRequestWithResponse(ctx, "POST", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateDocumentByFile 通过文件更新文档
+func (c *DatasetClient) UpdateDocumentByFile(ctx context.Context, datasetID, documentID string, filePath string, req *UpdateDocumentByFileRequest) (*DocumentResponse, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	return c.UpdateDocumentByFileFromReader(ctx, datasetID, documentID, file, filepath.Base(filePath), req)
+}
+
+// UpdateDocumentByFileFromReader 从 Reader 上传文件更新文档
+func (c *DatasetClient) UpdateDocumentByFileFromReader(ctx context.Context, datasetID, documentID string, reader io.Reader, filename string, req *UpdateDocumentByFileRequest) (*DocumentResponse, error) {
+	if req == nil {
+		req = &UpdateDocumentByFileRequest{}
+	}
+	path := fmt.Sprintf("/datasets/%s/documents/%s/update-by-file", datasetID, documentID)
+	return c.uploadDocument(ctx, path, reader, filename, req)
+}
+
+// uploadDocument 以 multipart 上传文档文件, 请求参数编码为 data 字段
+func (c *DatasetClient) uploadDocument(ctx context.Context, path string, reader io.Reader, filename string, req interface{}) (*DocumentResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	var resp DocumentResponse
+	fields := map[string]string{"data": string(data)}
+	err = c.doMultipartRequest(ctx, path, filename, reader, fields, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListDocuments 获取知识库文档列表, opts 可为 nil
+func (c *DatasetClient) ListDocuments(ctx context.Context, datasetID string, opts *DocumentListOptions) (*DocumentListResponse, error) {
+	if opts == nil {
+		opts = &DocumentListOptions{}
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(opts.Page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(opts.Limit)))
+	if opts.Keyword != "" {
+		query.Set("keyword", opts.Keyword)
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+
+	var resp DocumentListResponse
+	path := fmt.Sprintf("/datasets/%s/documents?%s", datasetID, query.Encode())
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetDocument 获取文档详情
+func (c *DatasetClient) GetDocument(ctx context.Context, datasetID, documentID string) (*Document, error) {
+	var resp Document
+	err := c.doRequestWithResponse(ctx, "GET", fmt.Sprintf("/datasets/%s/documents/%s", datasetID, documentID), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteDocument 删除文档
+func (c *DatasetClient) DeleteDocument(ctx context.Context, datasetID, documentID string) error {
+	return c.doRequestWithResponse(ctx, "DELETE", fmt.Sprintf("/datasets/%s/documents/%s", datasetID, documentID), nil, nil)
+}
@@ -0,0 +1,97 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreateDocumentByFileMultipart(t *testing.T) {
+	var (
+		filename string
+		content  string
+		data     map[string]interface{}
+	)
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/datasets/ds/document/create-by-file" {
+			http.Error(w, "unexpected request", http.StatusTeapot)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(file)
+		filename, content = header.Filename, string(b)
+
+		// 请求参数以 JSON 字符串放在 data 字段中
+		if err := json.Unmarshal([]byte(r.FormValue("data")), &data); err != nil {
+			http.Error(w, "invalid data field", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"document":{"id":"doc","name":"guide.md"},"batch":"batch-1"}`))
+	})
+
+	resp, err := client.CreateDocumentByFileFromReader(context.Background(), "ds", strings.NewReader("# Guide"), "guide.md", &CreateDocumentByFileRequest{
+		IndexingTechnique: IndexingTechniqueHighQuality,
+		ProcessRule:       &ProcessRule{Mode: ProcessModeAutomatic},
+	})
+	if err != nil {
+		t.Fatalf("CreateDocumentByFileFromReader() error = %v", err)
+	}
+	if resp.Document.ID != "doc" || resp.Batch != "batch-1" {
+		t.Errorf("response = %+v", resp)
+	}
+	if filename != "guide.md" || content != "# Guide" {
+		t.Errorf("file = %q %q, want guide.md", filename, content)
+	}
+
+	want := map[string]interface{}{
+		"indexing_technique": "high_quality",
+		"process_rule":       map[string]interface{}{"mode": "automatic"},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("data = %v, want %v", data, want)
+	}
+}
+
+func TestUpdateDocumentByFileNilRequest(t *testing.T) {
+	var data string
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/datasets/ds/documents/doc/update-by-file" {
+			http.Error(w, "unexpected request", http.StatusTeapot)
+			return
+		}
+		data = r.FormValue("data")
+		w.Write([]byte(`{"document":{"id":"doc"},"batch":"batch-2"}`))
+	})
+
+	if _, err := client.UpdateDocumentByFileFromReader(context.Background(), "ds", "doc", strings.NewReader("v2"), "guide.md", nil); err != nil {
+		t.Fatalf("UpdateDocumentByFileFromReader() error = %v", err)
+	}
+	// 请求为 nil 时仍发送合法的 JSON
+	if data != "{}" {
+		t.Errorf("data = %q, want {}", data)
+	}
+}
+
+func TestListDocumentsQuery(t *testing.T) {
+	var query string
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"data":[],"has_more":false,"limit":10,"total":0,"page":1}`))
+	})
+
+	_, err := client.ListDocuments(context.Background(), "ds", &DocumentListOptions{Keyword: "faq", Status: "indexing", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListDocuments() error = %v", err)
+	}
+	if want := "keyword=faq&limit=10&page=1&status=indexing"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
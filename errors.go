@@ -1,10 +1,19 @@
 package dify
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 )
 
+// maxErrorBodySize HTTPError 保留的响应体最大长度
+const maxErrorBodySize = 1024
+
 // APIError Dify API 错误
 type APIError struct {
 	StatusCode int    `json:"-"`
@@ -17,33 +26,209 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("dify api error: status=%d, code=%s, message=%s", e.StatusCode, e.Code, e.Message)
 }
 
+// Is 按错误码和状态码匹配哨兵错误, 用于 errors.Is
+func (e *APIError) Is(target error) bool {
+	if target == ErrConversationNotFound {
+		// 会话与消息不存在都返回 not_found, 只能依据消息 (如 "Conversation Not Exists.") 区分
+		return e.Code == ErrCodeNotFound && strings.Contains(strings.ToLower(e.Message), "conversation")
+	}
+
+	rule, ok := sentinelRules[target]
+	if !ok {
+		return false
+	}
+	return slices.Contains(rule.codes, e.Code) || rule.matchStatus(e.StatusCode)
+}
+
+// HTTPError 非 JSON 格式的错误响应 (如网关返回的 HTML 页面)
+type HTTPError struct {
+	StatusCode int
+	Header     http.Header
+	Body       string // 响应体, 超过 1KB 时被截断
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http error: status=%d, body=%s", e.StatusCode, e.Body)
+}
+
+// Is 按状态码匹配哨兵错误, 用于 errors.Is
+func (e *HTTPError) Is(target error) bool {
+	rule, ok := sentinelRules[target]
+	return ok && rule.matchStatus(e.StatusCode)
+}
+
 // ParseAPIError 解析 API 错误响应
+//
+// 响应体不是 Dify 错误 JSON 时返回 *HTTPError。
 func ParseAPIError(statusCode int, body []byte) error {
 	var apiErr APIError
-	if err := json.Unmarshal(body, &apiErr); err != nil {
-		return fmt.Errorf("http error: status=%d, body=%s", statusCode, string(body))
+	if err := json.Unmarshal(body, &apiErr); err != nil || (apiErr.Code == "" && apiErr.Message == "") {
+		return newHTTPError(statusCode, nil, body)
 	}
 	apiErr.StatusCode = statusCode
 	return &apiErr
 }
 
+// parseResponseError 解析错误响应, 保留响应头
+func parseResponseError(resp *http.Response, body []byte) error {
+	err := ParseAPIError(resp.StatusCode, body)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		httpErr.Header = resp.Header.Clone()
+	}
+	return err
+}
+
+// newHTTPError 创建 HTTPError, 截断过长的响应体
+func newHTTPError(statusCode int, header http.Header, body []byte) *HTTPError {
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	return &HTTPError{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       string(body),
+	}
+}
+
 // 常见错误码
 const (
-	ErrCodeNoAPIKey              = "no_api_key"
-	ErrCodeInvalidAPIKey         = "invalid_api_key"
-	ErrCodeAppUnavailable        = "app_unavailable"
-	ErrCodeProviderNotInitialize = "provider_not_initialize"
-	ErrCodeProviderQuotaExceeded = "provider_quota_exceeded"
+	ErrCodeNoAPIKey                 = "no_api_key"
+	ErrCodeInvalidAPIKey            = "invalid_api_key"
+	ErrCodeAppUnavailable           = "app_unavailable"
+	ErrCodeProviderNotInitialize    = "provider_not_initialize"
+	ErrCodeProviderQuotaExceeded    = "provider_quota_exceeded"
 	ErrCodeModelCurrentlyNotSupport = "model_currently_not_support"
-	ErrCodeCompletionRequestError = "completion_request_error"
-	ErrCodeNotFound              = "not_found"
-	ErrCodeNotChatApp            = "not_chat_app"
-	ErrCodeNotCompletionApp      = "not_completion_app"
-	ErrCodeConversationCompleted = "conversation_completed"
-	ErrCodeFileNotFound          = "file_not_found"
-	ErrCodeFileTooLarge          = "file_too_large"
-	ErrCodeUnsupportedFileType   = "unsupported_file_type"
-	ErrCodeS3ConnectionFailed    = "s3_connection_failed"
-	ErrCodeS3PermissionDenied    = "s3_permission_denied"
-	ErrCodeS3FileTooLarge        = "s3_file_too_large"
+	ErrCodeCompletionRequestError   = "completion_request_error"
+	ErrCodeNotFound                 = "not_found"
+	ErrCodeNotChatApp               = "not_chat_app"
+	ErrCodeNotCompletionApp         = "not_completion_app"
+	ErrCodeConversationCompleted    = "conversation_completed"
+	ErrCodeFileNotFound             = "file_not_found"
+	ErrCodeFileTooLarge             = "file_too_large"
+	ErrCodeUnsupportedFileType      = "unsupported_file_type"
+	ErrCodeS3ConnectionFailed       = "s3_connection_failed"
+	ErrCodeS3PermissionDenied       = "s3_permission_denied"
+	ErrCodeS3FileTooLarge           = "s3_file_too_large"
+	ErrCodeTooManyRequests          = "too_many_requests"
+)
+
+// 哨兵错误, 可通过 errors.Is 与 *APIError / *HTTPError / *StreamError 匹配
+var (
+	ErrInvalidAPIKey          = errors.New("dify: invalid api key")
+	ErrForbidden              = errors.New("dify: forbidden")
+	ErrNotFound               = errors.New("dify: not found")
+	ErrConversationNotFound   = errors.New("dify: conversation not found")
+	ErrRateLimited            = errors.New("dify: rate limited")
+	ErrQuotaExceeded          = errors.New("dify: provider quota exceeded")
+	ErrAppUnavailable         = errors.New("dify: app unavailable")
+	ErrProviderNotInitialized = errors.New("dify: provider not initialized")
+	ErrModelNotSupported      = errors.New("dify: model currently not supported")
+	ErrCompletionRequest      = errors.New("dify: completion request error")
+	ErrConversationCompleted  = errors.New("dify: conversation completed")
+	ErrFileNotFound           = errors.New("dify: file not found")
+	ErrFileTooLarge           = errors.New("dify: file too large")
+	ErrUnsupportedFileType    = errors.New("dify: unsupported file type")
+	ErrServerError            = errors.New("dify: server error")
 )
+
+// sentinelRule 哨兵错误的匹配规则
+type sentinelRule struct {
+	codes    []string
+	statuses []int
+	server   bool // 匹配所有 5xx 状态码
+}
+
+// matchStatus 判断状态码是否匹配
+func (r sentinelRule) matchStatus(statusCode int) bool {
+	if r.server && statusCode >= 500 && statusCode < 600 {
+		return true
+	}
+	return slices.Contains(r.statuses, statusCode)
+}
+
+var sentinelRules = map[error]sentinelRule{
+	ErrInvalidAPIKey:          {codes: []string{ErrCodeNoAPIKey, ErrCodeInvalidAPIKey}, statuses: []int{http.StatusUnauthorized}},
+	ErrForbidden:              {statuses: []int{http.StatusForbidden}},
+	ErrNotFound:               {codes: []string{ErrCodeNotFound}, statuses: []int{http.StatusNotFound}},
+	ErrRateLimited:            {codes: []string{ErrCodeTooManyRequests}, statuses: []int{http.StatusTooManyRequests}},
+	ErrQuotaExceeded:          {codes: []string{ErrCodeProviderQuotaExceeded}},
+	ErrAppUnavailable:         {codes: []string{ErrCodeAppUnavailable}},
+	ErrProviderNotInitialized: {codes: []string{ErrCodeProviderNotInitialize}},
+	ErrModelNotSupported:      {codes: []string{ErrCodeModelCurrentlyNotSupport}},
+	ErrCompletionRequest:      {codes: []string{ErrCodeCompletionRequestError}},
+	ErrConversationCompleted:  {codes: []string{ErrCodeConversationCompleted}},
+	ErrFileNotFound:           {codes: []string{ErrCodeFileNotFound}},
+	ErrFileTooLarge:           {codes: []string{ErrCodeFileTooLarge, ErrCodeS3FileTooLarge}, statuses: []int{http.StatusRequestEntityTooLarge}},
+	ErrUnsupportedFileType:    {codes: []string{ErrCodeUnsupportedFileType}, statuses: []int{http.StatusUnsupportedMediaType}},
+	ErrServerError:            {server: true},
+}
+
+// IsRetryable 判断错误是否为可重试的临时错误
+//
+// 包括 429/502/503/504 响应、连接被拒绝等建立连接阶段的错误, 以及幂等请求 (GET、PUT、DELETE 等)
+// 的超时、连接被重置等传输层临时错误; 请求方法取自 *url.Error, 无法确定时按非幂等请求处理。
+// ctx 取消或超时、TLS 证书错误不可重试。
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return slices.Contains(DefaultRetryableStatusCodes, apiErr.StatusCode)
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return slices.Contains(DefaultRetryableStatusCodes, httpErr.StatusCode)
+	}
+
+	var method string
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// url.Error.Op 为首字母大写的方法名, 如 "Get"
+		method = strings.ToUpper(urlErr.Op)
+	}
+	return isTransientNetError(method, err)
+}
+
+// IsAuthError 判断错误是否为鉴权失败 (API Key 缺失、无效或无权限)
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrInvalidAPIKey) || errors.Is(err, ErrForbidden)
+}
+
+// StreamError 流式响应中 Dify 返回的 error 事件
+type StreamError struct {
+	TaskID    string
+	MessageID string
+	Status    int
+	Code      string
+	Message   string
+	Err       *APIError
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("dify stream error: task_id=%s, status=%d, code=%s, message=%s", e.TaskID, e.Status, e.Code, e.Message)
+}
+
+// Unwrap 返回对应的 APIError
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// newStreamError 由 error 事件创建 StreamError
+func newStreamError(e *ErrorStreamEvent) *StreamError {
+	return &StreamError{
+		TaskID:    e.TaskID,
+		MessageID: e.MessageID,
+		Status:    e.Status,
+		Code:      e.Code,
+		Message:   e.Message,
+		Err: &APIError{
+			StatusCode: e.Status,
+			Code:       e.Code,
+			Message:    e.Message,
+			Status:     e.Status,
+		},
+	}
+}
@@ -0,0 +1,218 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *APIError
+		target error
+		want   bool
+	}{
+		{name: "no api key", err: &APIError{StatusCode: 401, Code: ErrCodeNoAPIKey}, target: ErrInvalidAPIKey, want: true},
+		{name: "invalid api key", err: &APIError{StatusCode: 400, Code: ErrCodeInvalidAPIKey}, target: ErrInvalidAPIKey, want: true},
+		{name: "unauthorized status", err: &APIError{StatusCode: 401, Code: "unauthorized"}, target: ErrInvalidAPIKey, want: true},
+		{name: "forbidden status", err: &APIError{StatusCode: 403, Code: "forbidden"}, target: ErrForbidden, want: true},
+		{name: "not found code", err: &APIError{StatusCode: 400, Code: ErrCodeNotFound}, target: ErrNotFound, want: true},
+		{name: "not found status", err: &APIError{StatusCode: 404, Code: "dataset_not_found"}, target: ErrNotFound, want: true},
+		{name: "rate limited code", err: &APIError{StatusCode: 400, Code: ErrCodeTooManyRequests}, target: ErrRateLimited, want: true},
+		{name: "rate limited status", err: &APIError{StatusCode: 429, Code: "rate_limit_error"}, target: ErrRateLimited, want: true},
+		{name: "quota exceeded", err: &APIError{StatusCode: 400, Code: ErrCodeProviderQuotaExceeded}, target: ErrQuotaExceeded, want: true},
+		{name: "app unavailable", err: &APIError{StatusCode: 400, Code: ErrCodeAppUnavailable}, target: ErrAppUnavailable, want: true},
+		{name: "provider not initialized", err: &APIError{StatusCode: 400, Code: ErrCodeProviderNotInitialize}, target: ErrProviderNotInitialized, want: true},
+		{name: "model not supported", err: &APIError{StatusCode: 400, Code: ErrCodeModelCurrentlyNotSupport}, target: ErrModelNotSupported, want: true},
+		{name: "completion request", err: &APIError{StatusCode: 400, Code: ErrCodeCompletionRequestError}, target: ErrCompletionRequest, want: true},
+		{name: "conversation completed", err: &APIError{StatusCode: 400, Code: ErrCodeConversationCompleted}, target: ErrConversationCompleted, want: true},
+		{name: "file not found", err: &APIError{StatusCode: 400, Code: ErrCodeFileNotFound}, target: ErrFileNotFound, want: true},
+		{name: "file too large", err: &APIError{StatusCode: 400, Code: ErrCodeFileTooLarge}, target: ErrFileTooLarge, want: true},
+		{name: "s3 file too large", err: &APIError{StatusCode: 400, Code: ErrCodeS3FileTooLarge}, target: ErrFileTooLarge, want: true},
+		{name: "payload too large status", err: &APIError{StatusCode: 413, Code: "payload_too_large"}, target: ErrFileTooLarge, want: true},
+		{name: "unsupported file type", err: &APIError{StatusCode: 400, Code: ErrCodeUnsupportedFileType}, target: ErrUnsupportedFileType, want: true},
+		{name: "unsupported media status", err: &APIError{StatusCode: 415, Code: "unsupported_media"}, target: ErrUnsupportedFileType, want: true},
+		{name: "server error 500", err: &APIError{StatusCode: 500, Code: "internal_server_error"}, target: ErrServerError, want: true},
+		{name: "server error 503", err: &APIError{StatusCode: 503, Code: "unavailable"}, target: ErrServerError, want: true},
+		{name: "bad request is not server error", err: &APIError{StatusCode: 400, Code: "invalid_param"}, target: ErrServerError, want: false},
+		{name: "unrelated code", err: &APIError{StatusCode: 400, Code: "invalid_param"}, target: ErrNotFound, want: false},
+		{name: "unknown target", err: &APIError{StatusCode: 404, Code: ErrCodeNotFound}, target: errors.New("other"), want: false},
+
+		// ErrConversationNotFound 依赖消息中的 "conversation", Dify 对会话不存在返回 "Conversation Not Exists."
+		{name: "conversation not exists", err: &APIError{StatusCode: 404, Code: ErrCodeNotFound, Message: "Conversation Not Exists."}, target: ErrConversationNotFound, want: true},
+		{name: "conversation also not found", err: &APIError{StatusCode: 404, Code: ErrCodeNotFound, Message: "Conversation Not Exists."}, target: ErrNotFound, want: true},
+		{name: "message not exists", err: &APIError{StatusCode: 404, Code: ErrCodeNotFound, Message: "Message Not Exists."}, target: ErrConversationNotFound, want: false},
+		{name: "conversation message other code", err: &APIError{StatusCode: 400, Code: ErrCodeConversationCompleted, Message: "Conversation has ended."}, target: ErrConversationNotFound, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+			// 经过包装后仍能匹配
+			wrapped := fmt.Errorf("chat: %w", tt.err)
+			if got := errors.Is(wrapped, tt.target); got != tt.want {
+				t.Errorf("errors.Is(wrapped, %v) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorIs(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+		want   bool
+	}{
+		{status: 401, target: ErrInvalidAPIKey, want: true},
+		{status: 403, target: ErrForbidden, want: true},
+		{status: 404, target: ErrNotFound, want: true},
+		{status: 404, target: ErrConversationNotFound, want: false},
+		{status: 413, target: ErrFileTooLarge, want: true},
+		{status: 415, target: ErrUnsupportedFileType, want: true},
+		{status: 429, target: ErrRateLimited, want: true},
+		{status: 502, target: ErrServerError, want: true},
+		{status: 400, target: ErrServerError, want: false},
+		{status: 400, target: ErrQuotaExceeded, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %v", tt.status, tt.target), func(t *testing.T) {
+			err := &HTTPError{StatusCode: tt.status, Body: "<html>error</html>"}
+			if got := errors.Is(err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%d, %v) = %v, want %v", tt.status, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantAPI  bool
+		wantCode string
+	}{
+		{name: "dify error", body: `{"code":"invalid_param","message":"bad","status":400}`, wantAPI: true, wantCode: "invalid_param"},
+		{name: "message only", body: `{"message":"bad"}`, wantAPI: true},
+		{name: "html", body: `<html>Bad Gateway</html>`},
+		{name: "unrelated json", body: `{"detail":"oops"}`},
+		{name: "empty", body: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseAPIError(http.StatusBadRequest, []byte(tt.body))
+
+			var apiErr *APIError
+			var httpErr *HTTPError
+			switch {
+			case tt.wantAPI:
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode || apiErr.StatusCode != http.StatusBadRequest {
+					t.Errorf("ParseAPIError() = %#v, want *APIError with code %q", err, tt.wantCode)
+				}
+			case !errors.As(err, &httpErr) || httpErr.Body != tt.body:
+				t.Errorf("ParseAPIError() = %#v, want *HTTPError with body %q", err, tt.body)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorFromResponse(t *testing.T) {
+	body := "<html>" + strings.Repeat("x", 2*maxErrorBodySize) + "</html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.doRequestWithResponse(context.Background(), http.MethodGet, "/parameters", nil, nil)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("StatusCode = %d, want 502", httpErr.StatusCode)
+	}
+	if httpErr.Body != body[:maxErrorBodySize] {
+		t.Errorf("Body length = %d, want truncated to %d", len(httpErr.Body), maxErrorBodySize)
+	}
+	if got := httpErr.Header.Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("Header X-Request-Id = %q, want req-1", got)
+	}
+	if !errors.Is(err, ErrServerError) || !IsRetryable(err) {
+		t.Errorf("error = %v, want ErrServerError and retryable", err)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "invalid api key", err: &APIError{StatusCode: 401, Code: ErrCodeInvalidAPIKey}, want: true},
+		{name: "no api key", err: &APIError{StatusCode: 401, Code: ErrCodeNoAPIKey}, want: true},
+		{name: "forbidden", err: &APIError{StatusCode: 403, Code: "forbidden"}, want: true},
+		{name: "http 401", err: &HTTPError{StatusCode: 401}, want: true},
+		{name: "stream error", err: &StreamError{Err: &APIError{StatusCode: 401, Code: ErrCodeInvalidAPIKey}}, want: true},
+		{name: "not found", err: &APIError{StatusCode: 404, Code: ErrCodeNotFound}, want: false},
+		{name: "server error", err: &HTTPError{StatusCode: 500}, want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.want {
+				t.Errorf("IsAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "rate limited", err: &APIError{StatusCode: 429, Code: ErrCodeTooManyRequests}, want: true},
+		{name: "bad gateway", err: &HTTPError{StatusCode: 502}, want: true},
+		{name: "service unavailable", err: &APIError{StatusCode: 503}, want: true},
+		{name: "gateway timeout", err: &HTTPError{StatusCode: 504}, want: true},
+		{name: "internal server error", err: &APIError{StatusCode: 500}, want: false},
+		{name: "bad request", err: &APIError{StatusCode: 400, Code: "invalid_param"}, want: false},
+		{name: "unauthorized", err: &HTTPError{StatusCode: 401}, want: false},
+		{name: "wrapped rate limited", err: fmt.Errorf("chat: %w", &APIError{StatusCode: 429}), want: true},
+		{name: "connection refused", err: &url.Error{Op: "Post", URL: "http://dify", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, want: true},
+		{name: "connection reset get", err: &url.Error{Op: "Get", URL: "http://dify", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, want: true},
+		{name: "connection reset post", err: &url.Error{Op: "Post", URL: "http://dify", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
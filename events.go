@@ -0,0 +1,208 @@
+package dify
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// 流式事件名称
+const (
+	EventMessage                = "message"
+	EventMessageEnd             = "message_end"
+	EventMessageReplace         = "message_replace"
+	EventMessageFile            = "message_file"
+	EventTTSMessage             = "tts_message"
+	EventTTSMessageEnd          = "tts_message_end"
+	EventAgentMessage           = "agent_message"
+	EventAgentThought           = "agent_thought"
+	EventWorkflowStarted        = "workflow_started"
+	EventNodeStarted            = "node_started"
+	EventNodeFinished           = "node_finished"
+	EventNodeRetry              = "node_retry"
+	EventIterationStarted       = "iteration_started"
+	EventIterationNext          = "iteration_next"
+	EventIterationCompleted     = "iteration_completed"
+	EventLoopStarted            = "loop_started"
+	EventLoopNext               = "loop_next"
+	EventLoopCompleted          = "loop_completed"
+	EventParallelBranchStarted  = "parallel_branch_started"
+	EventParallelBranchFinished = "parallel_branch_finished"
+	EventWorkflowFinished       = "workflow_finished"
+	EventTextChunk              = "text_chunk"
+	EventError                  = "error"
+)
+
+// Event 已解码的流式事件
+//
+// 该接口是封闭的, 只由本包中的事件类型实现; 未识别的事件以 *UnknownEvent 返回。
+type Event interface {
+	EventType() string
+	isEvent()
+}
+
+// UnknownEvent 未识别的流式事件, 保留原始 JSON 数据
+type UnknownEvent struct {
+	Event string
+	Data  json.RawMessage
+}
+
+func (e *MessageStreamEvent) EventType() string          { return e.Event }
+func (e *MessageEndStreamEvent) EventType() string       { return e.Event }
+func (e *MessageReplaceEvent) EventType() string         { return e.Event }
+func (e *MessageFileEvent) EventType() string            { return e.Event }
+func (e *TTSMessageEvent) EventType() string             { return e.Event }
+func (e *TTSMessageEndEvent) EventType() string          { return e.Event }
+func (e *AgentMessageEvent) EventType() string           { return e.Event }
+func (e *AgentThoughtEvent) EventType() string           { return e.Event }
+func (e *WorkflowStartedEvent) EventType() string        { return e.Event }
+func (e *NodeStartedEvent) EventType() string            { return e.Event }
+func (e *NodeFinishedEvent) EventType() string           { return e.Event }
+func (e *NodeRetryEvent) EventType() string              { return e.Event }
+func (e *IterationStartedEvent) EventType() string       { return e.Event }
+func (e *IterationNextEvent) EventType() string          { return e.Event }
+func (e *IterationCompletedEvent) EventType() string     { return e.Event }
+func (e *LoopStartedEvent) EventType() string            { return e.Event }
+func (e *LoopNextEvent) EventType() string               { return e.Event }
+func (e *LoopCompletedEvent) EventType() string          { return e.Event }
+func (e *ParallelBranchStartedEvent) EventType() string  { return e.Event }
+func (e *ParallelBranchFinishedEvent) EventType() string { return e.Event }
+func (e *WorkflowFinishedEvent) EventType() string       { return e.Event }
+func (e *TextChunkEvent) EventType() string              { return e.Event }
+func (e *ErrorStreamEvent) EventType() string            { return e.Event }
+func (e *UnknownEvent) EventType() string                { return e.Event }
+
+func (*MessageStreamEvent) isEvent()          {}
+func (*MessageEndStreamEvent) isEvent()       {}
+func (*MessageReplaceEvent) isEvent()         {}
+func (*MessageFileEvent) isEvent()            {}
+func (*TTSMessageEvent) isEvent()             {}
+func (*TTSMessageEndEvent) isEvent()          {}
+func (*AgentMessageEvent) isEvent()           {}
+func (*AgentThoughtEvent) isEvent()           {}
+func (*WorkflowStartedEvent) isEvent()        {}
+func (*NodeStartedEvent) isEvent()            {}
+func (*NodeFinishedEvent) isEvent()           {}
+func (*NodeRetryEvent) isEvent()              {}
+func (*IterationStartedEvent) isEvent()       {}
+func (*IterationNextEvent) isEvent()          {}
+func (*IterationCompletedEvent) isEvent()     {}
+func (*LoopStartedEvent) isEvent()            {}
+func (*LoopNextEvent) isEvent()               {}
+func (*LoopCompletedEvent) isEvent()          {}
+func (*ParallelBranchStartedEvent) isEvent()  {}
+func (*ParallelBranchFinishedEvent) isEvent() {}
+func (*WorkflowFinishedEvent) isEvent()       {}
+func (*TextChunkEvent) isEvent()              {}
+func (*ErrorStreamEvent) isEvent()            {}
+func (*UnknownEvent) isEvent()                {}
+
+// newEvent 根据事件名称创建对应的事件结构
+func newEvent(name string) Event {
+	switch name {
+	case EventMessage:
+		return &MessageStreamEvent{}
+	case EventMessageEnd:
+		return &MessageEndStreamEvent{}
+	case EventMessageReplace:
+		return &MessageReplaceEvent{}
+	case EventMessageFile:
+		return &MessageFileEvent{}
+	case EventTTSMessage:
+		return &TTSMessageEvent{}
+	case EventTTSMessageEnd:
+		return &TTSMessageEndEvent{}
+	case EventAgentMessage:
+		return &AgentMessageEvent{}
+	case EventAgentThought:
+		return &AgentThoughtEvent{}
+	case EventWorkflowStarted:
+		return &WorkflowStartedEvent{}
+	case EventNodeStarted:
+		return &NodeStartedEvent{}
+	case EventNodeFinished:
+		return &NodeFinishedEvent{}
+	case EventNodeRetry:
+		return &NodeRetryEvent{}
+	case EventIterationStarted:
+		return &IterationStartedEvent{}
+	case EventIterationNext:
+		return &IterationNextEvent{}
+	case EventIterationCompleted:
+		return &IterationCompletedEvent{}
+	case EventLoopStarted:
+		return &LoopStartedEvent{}
+	case EventLoopNext:
+		return &LoopNextEvent{}
+	case EventLoopCompleted:
+		return &LoopCompletedEvent{}
+	case EventParallelBranchStarted:
+		return &ParallelBranchStartedEvent{}
+	case EventParallelBranchFinished:
+		return &ParallelBranchFinishedEvent{}
+	case EventWorkflowFinished:
+		return &WorkflowFinishedEvent{}
+	case EventTextChunk:
+		return &TextChunkEvent{}
+	case EventError:
+		return &ErrorStreamEvent{}
+	}
+	return nil
+}
+
+// DecodeEvent 将 SSE 消息解码为具体的事件类型
+func DecodeEvent(msg *SSEMessage) (Event, error) {
+	event := newEvent(msg.Event)
+	if event == nil {
+		return &UnknownEvent{
+			Event: msg.Event,
+			Data:  json.RawMessage(msg.Data),
+		}, nil
+	}
+
+	if err := json.Unmarshal([]byte(msg.Data), event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s event: %w", msg.Event, err)
+	}
+	return event, nil
+}
+
+// IterationIndex 返回节点在所属迭代中的轮次 (从 0 开始)
+func (d *NodeFinishedData) IterationIndex() (int, bool) {
+	return metadataInt(d.ExecutionMetadata, "iteration_index")
+}
+
+// LoopIndex 返回节点在所属循环中的轮次 (从 0 开始)
+func (d *NodeFinishedData) LoopIndex() (int, bool) {
+	return metadataInt(d.ExecutionMetadata, "loop_index")
+}
+
+// Round 返回节点在所属迭代或循环中的轮次 (从 0 开始), 顶层节点返回 false
+//
+// 并行迭代中多轮交错执行, 只有 node_finished 携带的轮次是可靠的。
+func (d *NodeFinishedData) Round() (int, bool) {
+	switch {
+	case d.IterationID != "":
+		return d.IterationIndex()
+	case d.LoopID != "":
+		return d.LoopIndex()
+	}
+	return 0, false
+}
+
+// ContainerID 返回节点所属迭代或循环的 node_id, 顶层节点为空
+func (s NodeScope) ContainerID() string {
+	if s.IterationID != "" {
+		return s.IterationID
+	}
+	return s.LoopID
+}
+
+// metadataInt 从 execution_metadata 读取整数字段
+func metadataInt(metadata map[string]interface{}, key string) (int, bool) {
+	switch v := metadata[key].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,128 @@
+package dify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNodeFinishedDataRound(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		container string
+		round     int
+		ok        bool
+	}{
+		{name: "top level", data: `{"execution_metadata":{"iteration_index":2}}`},
+		{name: "iteration", data: `{"iteration_id":"it","execution_metadata":{"iteration_index":2}}`, container: "it", round: 2, ok: true},
+		{name: "loop", data: `{"loop_id":"loop","execution_metadata":{"loop_index":1}}`, container: "loop", round: 1, ok: true},
+		{name: "missing index", data: `{"loop_id":"loop","execution_metadata":{}}`, container: "loop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DecodeEvent(&SSEMessage{Event: EventNodeFinished, Data: `{"data":` + tt.data + `}`})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := event.(*NodeFinishedEvent).Data
+			if got := d.ContainerID(); got != tt.container {
+				t.Errorf("ContainerID() = %q, want %q", got, tt.container)
+			}
+			if round, ok := d.Round(); round != tt.round || ok != tt.ok {
+				t.Errorf("Round() = %d, %v, want %d, %v", round, ok, tt.round, tt.ok)
+			}
+		})
+	}
+}
+
+// newTestStream 由 SSE 文本创建流式读取器, 模拟 Dify 的流式响应
+func newTestStream(sse string) *StreamReader {
+	return NewStreamReader(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(sse)),
+	})
+}
+
+// readEvents 读取全部事件, 返回读取结束时的错误 (正常结束为 nil)
+func readEvents(sr *StreamReader) ([]Event, error) {
+	var events []Event
+	for {
+		event, err := sr.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+func TestStreamReaderNext(t *testing.T) {
+	stream := newTestStream("" +
+		"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"answer\":\"Hi\"}\n\n" +
+		": keep-alive comment\n\n" +
+		"event: ping\n\n" + // 没有数据的事件不分发
+		"data: {\"event\":\"node_started\",\"workflow_run_id\":\"run\",\"data\":{\"id\":\"e-1\",\"node_id\":\"llm\",\"iteration_id\":\"it\"}}\n\n" +
+		"data: {\"event\":\"workflow_paused\",\"task_id\":\"t\",\"data\":{\"reason\":\"human_input\"}}\n\n" +
+		"data: {\"event\":\"message_end\",\"task_id\":\"t\",\"message_id\":\"m\",\"metadata\":{\"usage\":{\"total_tokens\":7}}}\n\n")
+
+	events, err := readEvents(stream)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+
+	want := []string{EventMessage, EventNodeStarted, "workflow_paused", EventMessageEnd}
+	if len(events) != len(want) {
+		t.Fatalf("events = %d, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.EventType() != want[i] {
+			t.Errorf("event %d type = %q, want %q", i, event.EventType(), want[i])
+		}
+	}
+
+	if e, ok := events[0].(*MessageStreamEvent); !ok || e.Answer != "Hi" || e.MessageID != "m" {
+		t.Errorf("event 0 = %#v, want *MessageStreamEvent", events[0])
+	}
+	if e, ok := events[1].(*NodeStartedEvent); !ok || e.WorkflowRunID != "run" || e.Data.IterationID != "it" {
+		t.Errorf("event 1 = %#v, want *NodeStartedEvent in iteration", events[1])
+	}
+	if e, ok := events[3].(*MessageEndStreamEvent); !ok || e.Metadata.Usage.TotalTokens != 7 {
+		t.Errorf("event 3 = %#v, want *MessageEndStreamEvent", events[3])
+	}
+
+	// 未识别的事件保留原始数据, 调用方可以自行解码
+	unknown, ok := events[2].(*UnknownEvent)
+	if !ok {
+		t.Fatalf("event 2 = %#v, want *UnknownEvent", events[2])
+	}
+	var paused struct {
+		Data struct {
+			Reason string `json:"reason"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(unknown.Data, &paused); err != nil || paused.Data.Reason != "human_input" {
+		t.Errorf("UnknownEvent.Data = %s, want raw workflow_paused payload", unknown.Data)
+	}
+}
+
+func TestDecodeEventInvalidJSON(t *testing.T) {
+	_, err := DecodeEvent(&SSEMessage{Event: EventMessage, Data: `{"answer":`})
+	if err == nil || !strings.Contains(err.Error(), "message event") {
+		t.Errorf("DecodeEvent() error = %v, want unmarshal error", err)
+	}
+
+	// 未识别的事件不解析数据
+	event, err := DecodeEvent(&SSEMessage{Event: "custom", Data: `not json`})
+	if err != nil {
+		t.Fatalf("DecodeEvent() error = %v", err)
+	}
+	if e, ok := event.(*UnknownEvent); !ok || string(e.Data) != "not json" {
+		t.Errorf("DecodeEvent() = %#v, want *UnknownEvent", event)
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -64,8 +63,7 @@ func main() {
 	defer stream.Close()
 
 	for {
-		event, err := stream.Read()
-
+		event, err := stream.Next()
 		if err == io.EOF {
 			break
 		}
@@ -73,16 +71,20 @@ func main() {
 			log.Fatalf("读取流失败: %v", err)
 		}
 
-		fmt.Println("event", event.Event)
-		//fmt.Println("data", event.Data)
-
-		if event.Event == "message" {
-			var msg dify.MessageStreamEvent
-			if err := json.Unmarshal([]byte(event.Data), &msg); err == nil {
-				fmt.Print(msg.Answer)
-				conversationID = msg.ConversationID
-			}
-		} else if event.Event == "message_end" {
+		// 对话流 (advanced-chat) 应用会在消息中穿插工作流节点事件
+		switch e := event.(type) {
+		case *dify.MessageStreamEvent:
+			fmt.Print(e.Answer)
+			conversationID = e.ConversationID
+		case *dify.MessageReplaceEvent:
+			fmt.Printf("\n[内容已替换] %s\n", e.Answer)
+		case *dify.MessageFileEvent:
+			fmt.Printf("\n[文件] %s\n", e.URL)
+		case *dify.NodeStartedEvent:
+			fmt.Printf("\n[节点开始] %s (%s)\n", e.Data.Title, e.Data.NodeType)
+		case *dify.NodeFinishedEvent:
+			fmt.Printf("[节点完成] %s - %s\n", e.Data.Title, e.Data.Status)
+		case *dify.MessageEndStreamEvent:
 			fmt.Println("\n流式响应结束")
 		}
 	}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -51,7 +50,7 @@ func main() {
 	defer stream.Close()
 
 	for {
-		event, err := stream.Read()
+		event, err := stream.Next()
 		if err == io.EOF {
 			break
 		}
@@ -59,30 +58,30 @@ func main() {
 			log.Fatalf("读取流失败: %v", err)
 		}
 
-		switch event.Event {
-		case "workflow_started":
-			var e dify.WorkflowStartedEvent
-			json.Unmarshal([]byte(event.Data), &e)
+		switch e := event.(type) {
+		case *dify.WorkflowStartedEvent:
 			fmt.Printf("工作流开始: %s\n", e.WorkflowRunID)
-		case "node_started":
-			var e dify.NodeStartedEvent
-			json.Unmarshal([]byte(event.Data), &e)
-			fmt.Printf("节点开始: %s (%s)\n", e.Data.Title, e.Data.NodeType)
-		case "node_finished":
-			var e dify.NodeFinishedEvent
-			json.Unmarshal([]byte(event.Data), &e)
-			fmt.Printf("节点完成: %s - %s\n", e.Data.Title, e.Data.Status)
-		case "workflow_finished":
-			var e dify.WorkflowFinishedEvent
-			json.Unmarshal([]byte(event.Data), &e)
+		case *dify.NodeStartedEvent:
+			fmt.Printf("%s节点开始: %s (%s)\n", indent(e.Data.NodeScope), e.Data.Title, e.Data.NodeType)
+		case *dify.NodeFinishedEvent:
+			if round, ok := e.Data.Round(); ok {
+				fmt.Printf("%s节点完成: %s (第 %d 轮) - %s\n", indent(e.Data.NodeScope), e.Data.Title, round, e.Data.Status)
+			} else {
+				fmt.Printf("%s节点完成: %s - %s\n", indent(e.Data.NodeScope), e.Data.Title, e.Data.Status)
+			}
+		case *dify.NodeRetryEvent:
+			fmt.Printf("%s节点重试: %s 第 %d 次 (%s)\n", indent(e.Data.NodeScope), e.Data.Title, e.Data.RetryIndex, e.Data.Error)
+		case *dify.IterationStartedEvent:
+			fmt.Printf("迭代开始: %s\n", e.Data.Title)
+		case *dify.IterationNextEvent:
+			fmt.Printf("  第 %d 轮迭代\n", e.Data.Index)
+		case *dify.IterationCompletedEvent:
+			fmt.Printf("迭代完成: %s, 共 %d 步\n", e.Data.Title, e.Data.Steps)
+		case *dify.WorkflowFinishedEvent:
 			fmt.Printf("工作流完成: %s\n", e.Data.Status)
 			fmt.Printf("输出: %v\n", e.Data.Outputs)
-		case "text_chunk":
-			var data map[string]interface{}
-			json.Unmarshal([]byte(event.Data), &data)
-			if text, ok := data["text"].(string); ok {
-				fmt.Print(text)
-			}
+		case *dify.TextChunkEvent:
+			fmt.Print(e.Data.Text)
 		}
 	}
 
@@ -96,3 +95,11 @@ func main() {
 	fmt.Printf("总步骤: %d\n", status.TotalSteps)
 	fmt.Printf("总Token: %d\n", status.TotalTokens)
 }
+
+// indent 迭代或循环内的节点缩进显示
+func indent(scope dify.NodeScope) string {
+	if scope.ContainerID() != "" {
+		return "    "
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultIndexingPollInterval    = time.Second
+	DefaultIndexingMaxPollInterval = 10 * time.Second
+)
+
+// IndexingError 文档索引以 error、paused 或 stopped 结束
+type IndexingError struct {
+	DocumentID string
+	Status     string
+	Message    string
+}
+
+func (e *IndexingError) Error() string {
+	return fmt.Sprintf("dify indexing %s: document_id=%s, error=%s", e.Status, e.DocumentID, e.Message)
+}
+
+// IndexingWaitOptions 等待索引完成的参数
+type IndexingWaitOptions struct {
+	PollInterval    time.Duration // 初始轮询间隔 (默认 1s)
+	MaxPollInterval time.Duration // 最大轮询间隔 (默认 10s)
+
+	// OnProgress 每次轮询后回调当前进度
+	OnProgress func(statuses []IndexingStatus)
+}
+
+// GetIndexingStatus 获取批次中文档的索引进度
+func (c *DatasetClient) GetIndexingStatus(ctx context.Context, datasetID, batch string) (*IndexingStatusResponse, error) {
+	var resp IndexingStatusResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/indexing-status", datasetID, batch)
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// WaitForIndexing 轮询索引进度直到批次中所有文档结束索引
+//
+// 轮询间隔按 1.5 倍递增直至 MaxPollInterval。任一文档以 error、paused 或 stopped
+// 结束时返回最终进度和 *IndexingError; opts 可为 nil。
+func (c *DatasetClient) WaitForIndexing(ctx context.Context, datasetID, batch string, opts *IndexingWaitOptions) ([]IndexingStatus, error) {
+	if opts == nil {
+		opts = &IndexingWaitOptions{}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultIndexingPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultIndexingMaxPollInterval
+	}
+
+	for {
+		resp, err := c.GetIndexingStatus(ctx, datasetID, batch)
+		if err != nil {
+			return nil, err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(resp.Data)
+		}
+
+		if len(resp.Data) > 0 && indexingFinished(resp.Data) {
+			for _, status := range resp.Data {
+				if status.IndexingStatus != IndexingStatusCompleted {
+					return resp.Data, &IndexingError{
+						DocumentID: status.ID,
+						Status:     status.IndexingStatus,
+						Message:    status.Error,
+					}
+				}
+			}
+			return resp.Data, nil
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return resp.Data, err
+		}
+		interval = min(interval*3/2, maxInterval)
+	}
+}
+
+// indexingFinished 判断所有文档是否已结束索引
+func indexingFinished(statuses []IndexingStatus) bool {
+	for _, status := range statuses {
+		switch status.IndexingStatus {
+		case IndexingStatusCompleted, IndexingStatusError, IndexingStatusPaused, IndexingStatusStopped:
+		default:
+			return false
+		}
+	}
+	return true
+}
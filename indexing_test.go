@@ -0,0 +1,116 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newIndexingTestClient 依次返回 statuses 中的索引状态, 最后一个状态重复返回
+func newIndexingTestClient(t *testing.T, statuses ...string) (*DatasetClient, func() []time.Time) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		polls []time.Time
+	)
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/datasets/ds/documents/batch-1/indexing-status" {
+			http.Error(w, "unexpected request", http.StatusTeapot)
+			return
+		}
+		mu.Lock()
+		status := statuses[min(len(polls), len(statuses)-1)]
+		polls = append(polls, time.Now())
+		mu.Unlock()
+
+		if status == "" {
+			// 批次刚创建时可能还没有文档
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data":[{"id":"doc-1","indexing_status":"completed"},{"id":"doc-2","indexing_status":%q,"error":"parse failed"}]}`, status)
+	})
+
+	return client, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return polls
+	}
+}
+
+func TestWaitForIndexing(t *testing.T) {
+	client, polls := newIndexingTestClient(t, "", IndexingStatusWaiting, IndexingStatusIndexing, IndexingStatusIndexing, IndexingStatusCompleted)
+
+	var progress int
+	statuses, err := client.WaitForIndexing(context.Background(), "ds", "batch-1", &IndexingWaitOptions{
+		PollInterval:    10 * time.Millisecond,
+		MaxPollInterval: 20 * time.Millisecond,
+		OnProgress:      func([]IndexingStatus) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("WaitForIndexing() error = %v", err)
+	}
+	if len(statuses) != 2 || statuses[1].IndexingStatus != IndexingStatusCompleted {
+		t.Errorf("statuses = %+v", statuses)
+	}
+
+	times := polls()
+	if len(times) != 5 || progress != 5 {
+		t.Fatalf("polls = %d, progress callbacks = %d, want 5", len(times), progress)
+	}
+	// 间隔按 1.5 倍递增, 不超过 MaxPollInterval
+	want := []time.Duration{10 * time.Millisecond, 15 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond}
+	for i, wantGap := range want {
+		if gap := times[i+1].Sub(times[i]); gap < wantGap {
+			t.Errorf("interval %d = %v, want at least %v", i+1, gap, wantGap)
+		}
+	}
+}
+
+func TestWaitForIndexingFailed(t *testing.T) {
+	for _, status := range []string{IndexingStatusError, IndexingStatusPaused, IndexingStatusStopped} {
+		t.Run(status, func(t *testing.T) {
+			client, polls := newIndexingTestClient(t, IndexingStatusIndexing, status)
+
+			statuses, err := client.WaitForIndexing(context.Background(), "ds", "batch-1", &IndexingWaitOptions{PollInterval: time.Millisecond})
+			var indexingErr *IndexingError
+			if !errors.As(err, &indexingErr) {
+				t.Fatalf("error = %v, want *IndexingError", err)
+			}
+			if indexingErr.DocumentID != "doc-2" || indexingErr.Status != status || indexingErr.Message != "parse failed" {
+				t.Errorf("IndexingError = %+v", indexingErr)
+			}
+			if len(statuses) != 2 || len(polls()) != 2 {
+				t.Errorf("statuses = %d, polls = %d, want 2 and 2", len(statuses), len(polls()))
+			}
+		})
+	}
+}
+
+func TestWaitForIndexingCanceled(t *testing.T) {
+	client, polls := newIndexingTestClient(t, IndexingStatusIndexing)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statuses, err := client.WaitForIndexing(ctx, "ds", "batch-1", &IndexingWaitOptions{
+		PollInterval: time.Millisecond,
+		OnProgress: func([]IndexingStatus) {
+			if len(polls()) == 2 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	// 取消时返回最近一次的进度
+	if len(statuses) != 2 || len(polls()) != 2 {
+		t.Errorf("statuses = %+v, polls = %d, want last progress after 2 polls", statuses, len(polls()))
+	}
+}
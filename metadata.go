@@ -0,0 +1,173 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 元数据过滤的逻辑运算符
+const (
+	LogicalOperatorAnd = "and"
+	LogicalOperatorOr  = "or"
+)
+
+// 元数据过滤的比较运算符
+const (
+	MetadataOpContains       = "contains"
+	MetadataOpNotContains    = "not contains"
+	MetadataOpStartWith      = "start with"
+	MetadataOpEndWith        = "end with"
+	MetadataOpIs             = "is"
+	MetadataOpIsNot          = "is not"
+	MetadataOpEmpty          = "empty"
+	MetadataOpNotEmpty       = "not empty"
+	MetadataOpEqual          = "="
+	MetadataOpNotEqual       = "≠"
+	MetadataOpGreater        = ">"
+	MetadataOpLess           = "<"
+	MetadataOpGreaterOrEqual = "≥"
+	MetadataOpLessOrEqual    = "≤"
+	MetadataOpBefore         = "before"
+	MetadataOpAfter          = "after"
+)
+
+// CreateMetadataField 创建元数据字段
+func (c *DatasetClient) CreateMetadataField(ctx context.Context, datasetID string, req *CreateMetadataFieldRequest) (*MetadataField, error) {
+	var resp MetadataField
+	err := c.doRequestWithResponse(ctx, "POST", fmt.Sprintf("/datasets/%s/metadata", datasetID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListMetadataFields 获取元数据字段列表
+func (c *DatasetClient) ListMetadataFields(ctx context.Context, datasetID string) (*MetadataFieldListResponse, error) {
+	var resp MetadataFieldListResponse
+	err := c.doRequestWithResponse(ctx, "GET", fmt.Sprintf("/datasets/%s/metadata", datasetID), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RenameMetadataField 重命名元数据字段
+func (c *DatasetClient) RenameMetadataField(ctx context.Context, datasetID, metadataID, name string) (*MetadataField, error) {
+	req := map[string]string{"name": name}
+	var resp MetadataField
+	err := c.doRequestWithResponse(ctx, "PATCH", fmt.Sprintf("/datasets/%s/metadata/%s", datasetID, metadataID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteMetadataField 删除元数据字段
+func (c *DatasetClient) DeleteMetadataField(ctx context.Context, datasetID, metadataID string) error {
+	return c.doRequestWithResponse(ctx, "DELETE", fmt.Sprintf("/datasets/%s/metadata/%s", datasetID, metadataID), nil, nil)
+}
+
+// SetBuiltInMetadataEnabled 启用或禁用内置元数据字段
+func (c *DatasetClient) SetBuiltInMetadataEnabled(ctx context.Context, datasetID string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	return c.doRequestWithResponse(ctx, "POST", fmt.Sprintf("/datasets/%s/metadata/built-in/%s", datasetID, action), nil, nil)
+}
+
+// UpdateDocumentsMetadata 批量更新文档的元数据值
+func (c *DatasetClient) UpdateDocumentsMetadata(ctx context.Context, datasetID string, operations []DocumentMetadataOperation) error {
+	req := &UpdateDocumentsMetadataRequest{OperationData: operations}
+	return c.doRequestWithResponse(ctx, "POST", fmt.Sprintf("/datasets/%s/documents/metadata", datasetID), req, nil)
+}
+
+// MetadataFilter 元数据过滤条件构造器
+//
+//	filter := dify.MatchAll().
+//		Is("category", "faq").
+//		After("published_at", since)
+//	model.MetadataFilteringConditions = filter.Build()
+type MetadataFilter struct {
+	logicalOperator string
+	conditions      []MetadataCondition
+}
+
+// MatchAll 创建要求满足全部条件的过滤器
+func MatchAll() *MetadataFilter {
+	return &MetadataFilter{logicalOperator: LogicalOperatorAnd}
+}
+
+// MatchAny 创建满足任一条件即可的过滤器
+func MatchAny() *MetadataFilter {
+	return &MetadataFilter{logicalOperator: LogicalOperatorOr}
+}
+
+// Where 添加任意比较条件
+func (f *MetadataFilter) Where(name, operator string, value interface{}) *MetadataFilter {
+	f.conditions = append(f.conditions, MetadataCondition{
+		Name:               name,
+		ComparisonOperator: operator,
+		Value:              value,
+	})
+	return f
+}
+
+// Is 字符串等于
+func (f *MetadataFilter) Is(name, value string) *MetadataFilter {
+	return f.Where(name, MetadataOpIs, value)
+}
+
+// IsNot 字符串不等于
+func (f *MetadataFilter) IsNot(name, value string) *MetadataFilter {
+	return f.Where(name, MetadataOpIsNot, value)
+}
+
+// Contains 字符串包含
+func (f *MetadataFilter) Contains(name, value string) *MetadataFilter {
+	return f.Where(name, MetadataOpContains, value)
+}
+
+// Equal 数值等于
+func (f *MetadataFilter) Equal(name string, value float64) *MetadataFilter {
+	return f.Where(name, MetadataOpEqual, value)
+}
+
+// GreaterThan 数值大于
+func (f *MetadataFilter) GreaterThan(name string, value float64) *MetadataFilter {
+	return f.Where(name, MetadataOpGreater, value)
+}
+
+// LessThan 数值小于
+func (f *MetadataFilter) LessThan(name string, value float64) *MetadataFilter {
+	return f.Where(name, MetadataOpLess, value)
+}
+
+// Before 时间早于
+func (f *MetadataFilter) Before(name string, t time.Time) *MetadataFilter {
+	return f.Where(name, MetadataOpBefore, t.Unix())
+}
+
+// After 时间晚于
+func (f *MetadataFilter) After(name string, t time.Time) *MetadataFilter {
+	return f.Where(name, MetadataOpAfter, t.Unix())
+}
+
+// Empty 值为空
+func (f *MetadataFilter) Empty(name string) *MetadataFilter {
+	return f.Where(name, MetadataOpEmpty, nil)
+}
+
+// NotEmpty 值不为空
+func (f *MetadataFilter) NotEmpty(name string) *MetadataFilter {
+	return f.Where(name, MetadataOpNotEmpty, nil)
+}
+
+// Build 生成可用于 RetrievalModel 的过滤条件
+func (f *MetadataFilter) Build() *MetadataFilteringConditions {
+	return &MetadataFilteringConditions{
+		LogicalOperator: f.logicalOperator,
+		Conditions:      append([]MetadataCondition(nil), f.conditions...),
+	}
+}
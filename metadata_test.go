@@ -0,0 +1,117 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetadataFilterJSON(t *testing.T) {
+	since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter *MetadataFilter
+		want   string
+	}{
+		{
+			name:   "match all",
+			filter: MatchAll().Is("category", "faq").GreaterThan("version", 2).After("published_at", since),
+			want: `{"logical_operator":"and","conditions":[` +
+				`{"name":"category","comparison_operator":"is","value":"faq"},` +
+				`{"name":"version","comparison_operator":">","value":2},` +
+				`{"name":"published_at","comparison_operator":"after","value":1735787045}]}`,
+		},
+		{
+			name:   "match any without values",
+			filter: MatchAny().Empty("owner").NotEmpty("tags").Equal("score", 0),
+			want: `{"logical_operator":"or","conditions":[` +
+				`{"name":"owner","comparison_operator":"empty"},` +
+				`{"name":"tags","comparison_operator":"not empty"},` +
+				`{"name":"score","comparison_operator":"=","value":0}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.filter.Build())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// encoding/json 会转义 ">" 等字符, 因此按解码后的值比较
+			var gotValue, wantValue interface{}
+			json.Unmarshal(got, &gotValue)
+			json.Unmarshal([]byte(tt.want), &wantValue)
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("JSON = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataFilterBuildCopies(t *testing.T) {
+	filter := MatchAll().Is("category", "faq")
+	built := filter.Build()
+	filter.Is("lang", "zh")
+
+	if len(built.Conditions) != 1 {
+		t.Errorf("conditions = %d, want 1 (Build result must not change)", len(built.Conditions))
+	}
+}
+
+func TestRetrieveWithMetadataFilter(t *testing.T) {
+	var body struct {
+		RetrievalModel struct {
+			MetadataFilteringConditions MetadataFilteringConditions `json:"metadata_filtering_conditions"`
+		} `json:"retrieval_model"`
+	}
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		w.Write([]byte(`{"query":{"content":"q"},"records":[]}`))
+	})
+
+	model := &RetrievalModel{SearchMethod: SearchMethodSemantic, TopK: 5}
+	model.MetadataFilteringConditions = MatchAny().Contains("title", "退款").Build()
+	if _, err := client.Retrieve(context.Background(), "ds", "q", model); err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+
+	got := body.RetrievalModel.MetadataFilteringConditions
+	if got.LogicalOperator != LogicalOperatorOr || len(got.Conditions) != 1 ||
+		got.Conditions[0].ComparisonOperator != MetadataOpContains || got.Conditions[0].Value != "退款" {
+		t.Errorf("metadata_filtering_conditions = %+v", got)
+	}
+}
+
+func TestUpdateDocumentsMetadata(t *testing.T) {
+	var (
+		path string
+		body string
+	)
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		path, body = r.Method+" "+r.URL.Path, string(b)
+		w.Write([]byte(`{"result":"success"}`))
+	})
+
+	err := client.UpdateDocumentsMetadata(context.Background(), "ds", []DocumentMetadataOperation{{
+		DocumentID:   "doc",
+		MetadataList: []DocumentMetadataValue{{ID: "m-1", Name: "category", Value: "faq"}},
+	}})
+	if err != nil {
+		t.Fatalf("UpdateDocumentsMetadata() error = %v", err)
+	}
+	if path != "POST /datasets/ds/documents/metadata" {
+		t.Errorf("request = %s", path)
+	}
+	want := `{"operation_data":[{"document_id":"doc","metadata_list":[{"id":"m-1","name":"category","value":"faq"}]}]}`
+	if body != want {
+		t.Errorf("body = %s\nwant %s", body, want)
+	}
+}
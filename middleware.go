@@ -0,0 +1,38 @@
+package dify
+
+import "net/http"
+
+// RoundTripFunc 执行单次 HTTP 请求的函数
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip 实现 http.RoundTripper
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware 请求中间件, 包装下一个 RoundTripFunc
+//
+// 中间件作用于 SDK 发出的所有请求 (JSON、流式及 multipart 上传),
+// 每次重试都会重新经过中间件链。
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// chainMiddlewares 组装中间件链, 第一个中间件位于最外层
+func chainMiddlewares(final RoundTripFunc, middlewares []Middleware) RoundTripFunc {
+	next := final
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			next = middlewares[i](next)
+		}
+	}
+	return next
+}
+
+// WithHeader 返回为每个请求设置固定请求头的中间件
+func WithHeader(key, value string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(key, value)
+			return next(req)
+		}
+	}
+}
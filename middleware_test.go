@@ -0,0 +1,136 @@
+package dify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordMiddleware 记录请求经过中间件的顺序
+func recordMiddleware(name string, mu *sync.Mutex, calls *[]string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			*calls = append(*calls, name+" before")
+			mu.Unlock()
+
+			resp, err := next(req)
+
+			mu.Lock()
+			*calls = append(*calls, name+" after")
+			mu.Unlock()
+			return resp, err
+		}
+	}
+}
+
+func TestChainMiddlewaresOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	final := func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "transport")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}
+
+	rt := chainMiddlewares(final, []Middleware{
+		recordMiddleware("first", &mu, &calls),
+		nil, // nil 中间件被跳过
+		recordMiddleware("second", &mu, &calls),
+	})
+	if _, err := rt(httptest.NewRequest(http.MethodGet, "http://dify/", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"first before", "second before", "transport", "second after", "first after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMiddlewareEveryRetryAttempt(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		headers []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Get("X-Tenant"))
+		attempt := len(headers)
+		mu.Unlock()
+
+		if attempt < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer server.Close()
+
+	var calls []string
+	client := newRetryTestClient(t, server.URL, nil,
+		WithHeader("X-Tenant", "acme"),
+		recordMiddleware("outer", &mu, &calls),
+	)
+
+	if err := client.doRequestWithResponse(context.Background(), http.MethodGet, "/parameters", nil, nil); err != nil {
+		t.Fatalf("doRequestWithResponse() error = %v", err)
+	}
+
+	if want := []string{"acme", "acme", "acme"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("X-Tenant per attempt = %v, want %v", headers, want)
+	}
+	if len(calls) != 6 {
+		t.Errorf("middleware calls = %v, want 3 attempts", calls)
+	}
+}
+
+func TestMiddlewareMultipartUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/files/upload" || r.Header.Get("X-Tenant") != "acme" {
+			http.Error(w, "missing tenant header", http.StatusBadRequest)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			http.Error(w, "not multipart", http.StatusBadRequest)
+			return
+		}
+		_, header, err := r.FormFile("file")
+		if err != nil || header.Filename != "notes.txt" || r.FormValue("user") != "u" {
+			http.Error(w, "bad form", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":"file-1","name":"notes.txt"}`))
+	}))
+	defer server.Close()
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	client, err := NewClient(ClientConfig{
+		APIKey:      "test-key",
+		BaseURL:     server.URL,
+		Middlewares: []Middleware{WithHeader("X-Tenant", "acme"), recordMiddleware("upload", &mu, &calls)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.UploadFileFromReader(context.Background(), strings.NewReader("hello"), "notes.txt", "u")
+	if err != nil {
+		t.Fatalf("UploadFileFromReader() error = %v", err)
+	}
+	if resp.ID != "file-1" {
+		t.Errorf("ID = %q, want file-1", resp.ID)
+	}
+	if len(calls) != 2 {
+		t.Errorf("middleware calls = %v, want one request", calls)
+	}
+}
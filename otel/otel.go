@@ -0,0 +1,123 @@
+// Package difyotel 基于 OpenTelemetry 实现 dify.Tracer
+//
+// 单独作为模块发布, 不使用追踪功能时核心包无需引入 OpenTelemetry 依赖。
+package difyotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dify "github.com/Angbro/dify-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 追踪器名称
+const instrumentationName = "github.com/Angbro/dify-go"
+
+// Config 追踪器配置
+type Config struct {
+	// TracerProvider 为 nil 时使用 otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+	// Propagator 为 nil 时使用 W3C Trace Context
+	Propagator propagation.TextMapPropagator
+}
+
+// Tracer 基于 OpenTelemetry 的 dify.Tracer
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ dify.Tracer = (*Tracer)(nil)
+
+// NewTracer 创建追踪器
+func NewTracer(config Config) *Tracer {
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	propagator := config.Propagator
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagator,
+	}
+}
+
+// StartSpan 开始一个 span
+//
+// 带有 HTTP 方法属性的 API 调用 span 为 client 类型, 节点 span 为 internal 类型。
+func (t *Tracer) StartSpan(ctx context.Context, name string, attrs ...dify.Attribute) (context.Context, dify.Span) {
+	kind := trace.SpanKindInternal
+	for _, attr := range attrs {
+		if attr.Key == dify.AttrHTTPMethod {
+			kind = trace.SpanKindClient
+			break
+		}
+	}
+
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(convertAttributes(attrs)...),
+	)
+	return ctx, &otelSpan{span: span}
+}
+
+// Inject 写入追踪上下文请求头
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// otelSpan 包装 trace.Span
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttributes(attrs ...dify.Attribute) {
+	s.span.SetAttributes(convertAttributes(attrs)...)
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+// convertAttributes 转换为 OpenTelemetry 属性
+func convertAttributes(attrs []dify.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, convertAttribute(attr))
+	}
+	return kvs
+}
+
+// convertAttribute 按值类型转换单个属性, 未知类型按字符串处理
+func convertAttribute(attr dify.Attribute) attribute.KeyValue {
+	switch v := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, v)
+	case bool:
+		return attribute.Bool(attr.Key, v)
+	case int:
+		return attribute.Int(attr.Key, v)
+	case int64:
+		return attribute.Int64(attr.Key, v)
+	case float64:
+		return attribute.Float64(attr.Key, v)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,23 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Retrieve 检索知识库 (命中测试)
+//
+// model 为 nil 时使用知识库自身的检索配置。
+func (c *DatasetClient) Retrieve(ctx context.Context, datasetID, query string, model *RetrievalModel) (*RetrieveResponse, error) {
+	req := &RetrieveRequest{
+		Query:          query,
+		RetrievalModel: model,
+	}
+
+	var resp RetrieveResponse
+	err := c.doRequestWithResponse(ctx, "POST", fmt.Sprintf("/datasets/%s/retrieve", datasetID), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,59 @@
+package dify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestRetrieve(t *testing.T) {
+	tests := []struct {
+		name  string
+		model *RetrievalModel
+		want  string
+	}{
+		{
+			name: "dataset settings",
+			want: `{"query":"退款政策"}`,
+		},
+		{
+			name: "hybrid with rerank",
+			model: &RetrievalModel{
+				SearchMethod:    SearchMethodHybrid,
+				RerankingEnable: true,
+				RerankingModel:  &RerankingModel{RerankingProviderName: "cohere", RerankingModelName: "rerank-v3"},
+				TopK:            3,
+			},
+			want: `{"query":"退款政策","retrieval_model":{"search_method":"hybrid_search","reranking_enable":true,` +
+				`"reranking_model":{"reranking_provider_name":"cohere","reranking_model_name":"rerank-v3"},` +
+				`"top_k":3,"score_threshold_enabled":false,"score_threshold":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/datasets/ds/retrieve" {
+					http.Error(w, "unexpected request", http.StatusTeapot)
+					return
+				}
+				b, _ := io.ReadAll(r.Body)
+				body = string(b)
+				w.Write([]byte(`{"query":{"content":"退款政策"},"records":[{"segment":{"id":"seg","content":"7 天内可退款"},"score":0.87}]}`))
+			})
+
+			resp, err := client.Retrieve(context.Background(), "ds", "退款政策", tt.model)
+			if err != nil {
+				t.Fatalf("Retrieve() error = %v", err)
+			}
+			if body != tt.want {
+				t.Errorf("body = %s\nwant %s", body, tt.want)
+			}
+			if resp.Query.Content != "退款政策" || len(resp.Records) != 1 || resp.Records[0].Segment.ID != "seg" || resp.Records[0].Score != 0.87 {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff  = 10 * time.Second
+	DefaultJitter      = 0.2
+)
+
+// maxRetryInspectSize 判断是否重试时最多读取的响应体长度
+const maxRetryInspectSize = 64 << 10
+
+// DefaultRetryableStatusCodes 默认可重试的 HTTP 状态码
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy 请求重试策略
+//
+// 流式请求只会在收到成功响应之前重试, 一旦响应返回给调用方便不再重试。
+type RetryPolicy struct {
+	MaxAttempts          int           // 最大尝试次数 (含首次请求, 默认 3)
+	BaseBackoff          time.Duration // 初始退避时间 (默认 500ms)
+	MaxBackoff           time.Duration // 最大退避时间 (默认 10s), Retry-After 超过该值时不再重试
+	Jitter               float64       // 抖动比例 0~1 (默认 0.2)
+	RetryableStatusCodes []int         // 可重试的 HTTP 状态码 (默认 429/502/503/504)
+	RetryableErrorCodes  []string      // 可重试的 APIError.Code
+}
+
+// DefaultRetryPolicy 返回默认重试策略
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          DefaultMaxAttempts,
+		BaseBackoff:          DefaultBaseBackoff,
+		MaxBackoff:           DefaultMaxBackoff,
+		Jitter:               DefaultJitter,
+		RetryableStatusCodes: slices.Clone(DefaultRetryableStatusCodes),
+	}
+}
+
+// normalize 返回填充默认值后的策略副本
+func (p *RetryPolicy) normalize() *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+
+	policy := *p
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.BaseBackoff <= 0 {
+		policy.BaseBackoff = DefaultBaseBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultMaxBackoff
+	}
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	if policy.RetryableStatusCodes == nil {
+		policy.RetryableStatusCodes = slices.Clone(DefaultRetryableStatusCodes)
+	} else {
+		policy.RetryableStatusCodes = slices.Clone(policy.RetryableStatusCodes)
+	}
+	policy.RetryableErrorCodes = slices.Clone(policy.RetryableErrorCodes)
+	return &policy
+}
+
+// backoff 计算第 attempt 次失败后的退避时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delta := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+	return delay
+}
+
+// shouldRetryError 判断请求错误是否可重试
+func (p *RetryPolicy) shouldRetryError(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return isTransientNetError(method, err)
+}
+
+// idempotentMethods 重放不会产生额外副作用的请求方法
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPut,
+	http.MethodDelete,
+}
+
+// isTransientNetError 判断传输层错误在给定请求方法下是否可以安全重试
+//
+// 建立连接阶段的失败 (如连接被拒绝) 说明请求尚未发出, 任何方法都可以重试;
+// 超时、连接被重置与连接意外断开时服务端可能已经处理了请求, 只对幂等方法重试,
+// 以免重复发送消息或运行工作流。TLS 证书校验失败、请求构造失败与中间件主动返回的错误
+// 都不可重试。
+func isTransientNetError(method string, err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if isDialError(err) {
+		return true
+	}
+	if !slices.Contains(idempotentMethods, method) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// *url.Error 也实现了 net.Error, 因此只看 Timeout
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isDialError 判断错误是否发生在建立连接阶段
+func isDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// shouldRetryResponse 判断响应是否可重试
+//
+// 需要检查错误码时会读取响应体开头的至多 64KB, 读取的内容会被放回响应体, 调用方可以照常读取。
+func (p *RetryPolicy) shouldRetryResponse(resp *http.Response) bool {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false
+	}
+	if slices.Contains(p.RetryableStatusCodes, resp.StatusCode) {
+		return true
+	}
+	if len(p.RetryableErrorCodes) == 0 {
+		return false
+	}
+
+	// 错误码位于响应体开头, 只读取有限长度, 其余内容留在原响应体中
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxRetryInspectSize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(respBody), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(ParseAPIError(resp.StatusCode, respBody), &apiErr) {
+		return slices.Contains(p.RetryableErrorCodes, apiErr.Code)
+	}
+	return false
+}
+
+// parseRetryAfter 解析 Retry-After 响应头 (秒数或 HTTP 日期)
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		delay := time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleepContext 等待指定时间, ctx 取消时提前返回
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// discardResponse 丢弃并关闭响应体, 以便复用连接
+func discardResponse(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxRetryInspectSize))
+	resp.Body.Close()
+}
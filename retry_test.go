@@ -0,0 +1,405 @@
+package dify
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := (&RetryPolicy{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}).normalize()
+	policy.Jitter = 0
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	// 次数很大时不应溢出
+	if got := policy.backoff(100); got != time.Second {
+		t.Errorf("backoff(100) = %v, want %v", got, time.Second)
+	}
+}
+
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	policy := (&RetryPolicy{
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  time.Second,
+		Jitter:      0.5,
+	}).normalize()
+
+	for range 100 {
+		got := policy.backoff(2)
+		if got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("backoff(2) = %v, want within [100ms, 300ms]", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "missing", value: "", wantOK: false},
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOK: true},
+		{name: "zero", value: "0", want: 0, wantOK: true},
+		{name: "negative", value: "-1", wantOK: false},
+		{name: "past date", value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "invalid", value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set("Retry-After", tt.value)
+			}
+
+			got, ok := parseRetryAfter(resp)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", time.Now().Add(30*time.Second).UTC().Format(http.TimeFormat))
+
+	got, ok := parseRetryAfter(resp)
+	if !ok {
+		t.Fatal("parseRetryAfter() ok = false, want true")
+	}
+	// HTTP 日期精度为秒
+	if got < 28*time.Second || got > 30*time.Second {
+		t.Errorf("parseRetryAfter() = %v, want about 30s", got)
+	}
+}
+
+func TestIsTransientNetError(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		want   bool
+	}{
+		{name: "nil", method: http.MethodGet, err: nil, want: false},
+		{name: "connection reset get", method: http.MethodGet, err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "connection reset post", method: http.MethodPost, err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: false},
+		{name: "connection refused post", method: http.MethodPost, err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "dial timeout post", method: http.MethodPost, err: &net.OpError{Op: "dial", Err: timeoutError{}}, want: true},
+		{name: "unexpected eof delete", method: http.MethodDelete, err: io.ErrUnexpectedEOF, want: true},
+		{name: "unexpected eof post", method: http.MethodPost, err: io.ErrUnexpectedEOF, want: false},
+		{name: "timeout put", method: http.MethodPut, err: timeoutError{}, want: true},
+		{name: "timeout post", method: http.MethodPost, err: timeoutError{}, want: false},
+		{name: "timeout patch", method: http.MethodPatch, err: timeoutError{}, want: false},
+		{name: "tls verification", method: http.MethodGet, err: &x509.UnknownAuthorityError{}, want: false},
+		{name: "middleware error", method: http.MethodGet, err: errors.New("denied"), want: false},
+		{name: "canceled", method: http.MethodGet, err: context.Canceled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.err != nil {
+				// 与 http.Client 一致, url.Error.Op 为首字母大写的方法名
+				op := tt.method[:1] + strings.ToLower(tt.method[1:])
+				err = fmt.Errorf("failed to execute request: %w", &url.Error{Op: op, URL: "http://dify", Err: tt.err})
+			}
+
+			if got := isTransientNetError(tt.method, err); got != tt.want {
+				t.Errorf("isTransientNetError() = %v, want %v", got, tt.want)
+			}
+			if got := IsRetryable(err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryableUnknownMethod(t *testing.T) {
+	// 没有 *url.Error 时无法确定请求方法, 按非幂等请求处理
+	if IsRetryable(&net.OpError{Op: "read", Err: syscall.ECONNRESET}) {
+		t.Error("IsRetryable(connection reset) = true, want false")
+	}
+	if !IsRetryable(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}) {
+		t.Error("IsRetryable(connection refused) = false, want true")
+	}
+	if IsRetryable(context.DeadlineExceeded) {
+		t.Error("IsRetryable(context.DeadlineExceeded) = true, want false")
+	}
+}
+
+// timeoutError 模拟超时的 net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// newRetryTestClient 创建不等待退避的测试客户端
+func newRetryTestClient(t *testing.T, baseURL string, policy *RetryPolicy, middlewares ...Middleware) *Client {
+	t.Helper()
+
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+	policy.BaseBackoff = time.Millisecond
+	policy.Jitter = 0
+
+	client, err := NewClient(ClientConfig{
+		APIKey:      "test-key",
+		BaseURL:     baseURL,
+		Retry:       policy,
+		Middlewares: middlewares,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestClientRetryReplaysBody(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		attempt := len(bodies)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(t, server.URL, nil)
+
+	var result map[string]string
+	err := client.doRequestWithResponse(context.Background(), http.MethodPost, "/chat-messages", map[string]string{"query": "hi"}, &result)
+	if err != nil {
+		t.Fatalf("doRequestWithResponse() error = %v", err)
+	}
+	if result["result"] != "success" {
+		t.Errorf("result = %v, want success", result)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(bodies))
+	}
+	for i, body := range bodies {
+		if body != `{"query":"hi"}` {
+			t.Errorf("attempt %d body = %q, want %q", i+1, body, `{"query":"hi"}`)
+		}
+	}
+}
+
+func TestClientRetryAfterExceedsMaxBackoff(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":"too_many_requests","message":"slow down","status":429}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(t, server.URL, &RetryPolicy{MaxBackoff: time.Second})
+
+	err := client.doRequestWithResponse(context.Background(), http.MethodPost, "/chat-messages", nil, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want ErrRateLimited", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestClientRetryableErrorCodes(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		if attempts == 1 {
+			w.Write([]byte(`{"code":"provider_not_initialize","message":"warming up","status":400}`))
+			return
+		}
+		w.Write([]byte(`{"code":"invalid_param","message":"bad query","status":400}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(t, server.URL, &RetryPolicy{
+		RetryableErrorCodes: []string{ErrCodeProviderNotInitialize},
+	})
+
+	err := client.doRequestWithResponse(context.Background(), http.MethodPost, "/chat-messages", nil, nil)
+
+	// 第二次响应的错误码不可重试, 读取错误码后响应体应被重新填充
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *APIError", err)
+	}
+	if apiErr.Code != "invalid_param" || apiErr.Message != "bad query" {
+		t.Errorf("APIError = %+v, want code invalid_param", apiErr)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestShouldRetryResponseRefillsBody(t *testing.T) {
+	policy := (&RetryPolicy{RetryableErrorCodes: []string{ErrCodeProviderNotInitialize}}).normalize()
+
+	const body = `{"code":"provider_not_initialize","message":"warming up","status":400}`
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+
+	if !policy.shouldRetryResponse(resp) {
+		t.Error("shouldRetryResponse() = false, want true")
+	}
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
+
+func TestClientRetryTransportErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		err          error
+		wantAttempts int
+	}{
+		{name: "connection reset get", method: http.MethodGet, path: "/messages", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, wantAttempts: 3},
+		{name: "timeout get", method: http.MethodGet, path: "/messages", err: timeoutError{}, wantAttempts: 3},
+		{name: "connection reset post", method: http.MethodPost, path: "/workflows/run", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, wantAttempts: 1},
+		{name: "timeout post", method: http.MethodPost, path: "/chat-messages", err: timeoutError{}, wantAttempts: 1},
+		{name: "connection refused post", method: http.MethodPost, path: "/workflows/run", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, wantAttempts: 3},
+		{name: "middleware error", method: http.MethodGet, path: "/messages", err: errors.New("denied by policy"), wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			fail := func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					attempts++
+					return nil, tt.err
+				}
+			}
+
+			client := newRetryTestClient(t, "http://dify.invalid", nil, fail)
+
+			err := client.doRequestWithResponse(context.Background(), tt.method, tt.path, nil, nil)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestShouldRetryResponseLargeBody(t *testing.T) {
+	policy := (&RetryPolicy{RetryableErrorCodes: []string{ErrCodeProviderNotInitialize}}).normalize()
+
+	body := `{"code":"invalid_param","message":"` + strings.Repeat("x", 2*maxRetryInspectSize) + `","status":400}`
+	src := &countingReader{r: strings.NewReader(body)}
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       io.NopCloser(src),
+	}
+
+	if policy.shouldRetryResponse(resp) {
+		t.Error("shouldRetryResponse() = true, want false")
+	}
+	if src.n > maxRetryInspectSize {
+		t.Errorf("read %d bytes, want at most %d", src.n, maxRetryInspectSize)
+	}
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("body length = %d, want %d", len(got), len(body))
+	}
+}
+
+// countingReader 统计已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestClientRetryTLSError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var attempts int
+	count := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return next(req)
+		}
+	}
+
+	client := newRetryTestClient(t, server.URL, nil, count)
+
+	err := client.doRequestWithResponse(context.Background(), http.MethodPost, "/chat-messages", nil, nil)
+	if err == nil {
+		t.Fatal("error = nil, want certificate error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
@@ -0,0 +1,142 @@
+package dify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// CreateSegments 向文档新增分段
+func (c *DatasetClient) CreateSegments(ctx context.Context, datasetID, documentID string, segments []SegmentInput) (*SegmentsResponse, error) {
+	req := &CreateSegmentsRequest{Segments: segments}
+	var resp SegmentsResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments", datasetID, documentID)
+	err := c.doRequestWithResponse(ctx, "POST", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListSegments 获取文档分段列表, opts 可为 nil
+func (c *DatasetClient) ListSegments(ctx context.Context, datasetID, documentID string, opts *SegmentListOptions) (*SegmentListResponse, error) {
+	if opts == nil {
+		opts = &SegmentListOptions{}
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(opts.Page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(opts.Limit)))
+	if opts.Keyword != "" {
+		query.Set("keyword", opts.Keyword)
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+
+	var resp SegmentListResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments?%s", datasetID, documentID, query.Encode())
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSegment 获取分段详情
+func (c *DatasetClient) GetSegment(ctx context.Context, datasetID, documentID, segmentID string) (*SegmentResponse, error) {
+	var resp SegmentResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s", datasetID, documentID, segmentID)
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateSegment 更新分段
+func (c *DatasetClient) UpdateSegment(ctx context.Context, datasetID, documentID, segmentID string, segment SegmentUpdate) (*SegmentResponse, error) {
+	req := &UpdateSegmentRequest{Segment: segment}
+	var resp SegmentResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s", datasetID, documentID, segmentID)
+	err := c.doRequestWithResponse(ctx, "POST", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteSegment 删除分段
+func (c *DatasetClient) DeleteSegment(ctx context.Context, datasetID, documentID, segmentID string) error {
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s", datasetID, documentID, segmentID)
+	return c.doRequestWithResponse(ctx, "DELETE", path, nil, nil)
+}
+
+// SetSegmentEnabled 启用或禁用分段
+//
+// 请求中只包含 enabled, 服务端保留分段原有内容, 不会覆盖并发的内容修改。
+func (c *DatasetClient) SetSegmentEnabled(ctx context.Context, datasetID, documentID, segmentID string, enabled bool) (*SegmentResponse, error) {
+	req := map[string]interface{}{
+		"segment": map[string]bool{"enabled": enabled},
+	}
+	var resp SegmentResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s", datasetID, documentID, segmentID)
+	err := c.doRequestWithResponse(ctx, "POST", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateChildChunk 在分段下新增子分段
+func (c *DatasetClient) CreateChildChunk(ctx context.Context, datasetID, documentID, segmentID, content string) (*ChildChunkResponse, error) {
+	req := map[string]string{"content": content}
+	var resp ChildChunkResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s/child_chunks", datasetID, documentID, segmentID)
+	err := c.doRequestWithResponse(ctx, "POST", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListChildChunks 获取子分段列表, opts 可为 nil
+func (c *DatasetClient) ListChildChunks(ctx context.Context, datasetID, documentID, segmentID string, opts *ChildChunkListOptions) (*ChildChunkListResponse, error) {
+	if opts == nil {
+		opts = &ChildChunkListOptions{}
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(opts.Page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(opts.Limit)))
+	if opts.Keyword != "" {
+		query.Set("keyword", opts.Keyword)
+	}
+
+	var resp ChildChunkListResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s/child_chunks?%s", datasetID, documentID, segmentID, query.Encode())
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateChildChunk 更新子分段
+func (c *DatasetClient) UpdateChildChunk(ctx context.Context, datasetID, documentID, segmentID, childChunkID, content string) (*ChildChunkResponse, error) {
+	req := map[string]string{"content": content}
+	var resp ChildChunkResponse
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s/child_chunks/%s", datasetID, documentID, segmentID, childChunkID)
+	err := c.doRequestWithResponse(ctx, "PATCH", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteChildChunk 删除子分段
+func (c *DatasetClient) DeleteChildChunk(ctx context.Context, datasetID, documentID, segmentID, childChunkID string) error {
+	path := fmt.Sprintf("/datasets/%s/documents/%s/segments/%s/child_chunks/%s", datasetID, documentID, segmentID, childChunkID)
+	return c.doRequestWithResponse(ctx, "DELETE", path, nil, nil)
+}
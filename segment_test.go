@@ -0,0 +1,47 @@
+package dify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestSetSegmentEnabled(t *testing.T) {
+	var requests []string
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		w.Write([]byte(`{"data":{"id":"seg","content":"edited elsewhere","enabled":false},"doc_form":"text_model"}`))
+	})
+
+	resp, err := client.SetSegmentEnabled(context.Background(), "ds", "doc", "seg", false)
+	if err != nil {
+		t.Fatalf("SetSegmentEnabled() error = %v", err)
+	}
+	if resp.Data.Enabled || resp.Data.Content != "edited elsewhere" {
+		t.Errorf("segment = %+v", resp.Data)
+	}
+
+	// 只发送 enabled, 不读取也不回写分段内容
+	want := `POST /datasets/ds/documents/doc/segments/seg {"segment":{"enabled":false}}`
+	if len(requests) != 1 || requests[0] != want {
+		t.Errorf("requests = %q, want [%q]", requests, want)
+	}
+}
+
+func TestListSegmentsQuery(t *testing.T) {
+	var query string
+	client := newDatasetTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"data":[],"has_more":false,"limit":20,"total":0,"page":2}`))
+	})
+
+	_, err := client.ListSegments(context.Background(), "ds", "doc", &SegmentListOptions{Keyword: "a&b", Status: "completed", Page: 2})
+	if err != nil {
+		t.Fatalf("ListSegments() error = %v", err)
+	}
+	if want := "keyword=a%26b&limit=20&page=2&status=completed"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
@@ -0,0 +1,196 @@
+package dify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxSSELineSize SSE 单行及单个事件数据的默认最大长度
+const DefaultMaxSSELineSize = 4 << 20
+
+// ErrSSELineTooLong SSE 行或事件数据超过最大长度
+var ErrSSELineTooLong = errors.New("sse line too long")
+
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// SSEMessage SSE 消息
+type SSEMessage struct {
+	Event string
+	Data  string
+	ID    string        // 最近一次 id 字段的值
+	Retry time.Duration // retry 字段指定的重连间隔, 未指定时为 0
+}
+
+// SSEReader SSE 事件读取器
+//
+// 按照 W3C EventSource 规范解析 text/event-stream:
+// 支持 CRLF/LF/CR 换行、BOM、注释行、多行 data 拼接以及 id/retry 字段。
+type SSEReader struct {
+	scanner     *bufio.Scanner
+	maxSize     int
+	started     bool
+	lastEventID string
+}
+
+// NewSSEReader 创建 SSE 读取器
+func NewSSEReader(reader io.Reader) *SSEReader {
+	return NewSSEReaderSize(reader, DefaultMaxSSELineSize)
+}
+
+// NewSSEReaderSize 创建指定最大行长度的 SSE 读取器
+func NewSSEReaderSize(reader io.Reader, maxSize int) *SSEReader {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSSELineSize
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, min(4096, maxSize)), maxSize)
+	scanner.Split(scanSSELines)
+
+	return &SSEReader{
+		scanner: scanner,
+		maxSize: maxSize,
+	}
+}
+
+// Read 读取下一个 SSE 事件
+//
+// 流结束时返回 io.EOF, 未以空行结束的残留数据按规范丢弃。
+func (r *SSEReader) Read() (*SSEMessage, error) {
+	var (
+		eventType string
+		data      bytes.Buffer
+		hasData   bool
+		retry     time.Duration
+	)
+
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if !r.started {
+			r.started = true
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+
+		// 空行: 分发事件
+		if len(line) == 0 {
+			if !hasData {
+				eventType = ""
+				retry = 0
+				continue
+			}
+			return r.dispatch(eventType, data.String(), retry), nil
+		}
+
+		// 注释行
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if idx := bytes.IndexByte(line, ':'); idx >= 0 {
+			field, value = line[:idx], line[idx+1:]
+			value = bytes.TrimPrefix(value, []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			if data.Len()+len(value) > r.maxSize {
+				return nil, fmt.Errorf("%w: event data exceeds %d bytes", ErrSSELineTooLong, r.maxSize)
+			}
+			data.Write(value)
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				r.lastEventID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 32); err == nil && isASCIIDigits(value) {
+				retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: line exceeds %d bytes", ErrSSELineTooLong, r.maxSize)
+		}
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// dispatch 组装待分发的事件
+func (r *SSEReader) dispatch(eventType, data string, retry time.Duration) *SSEMessage {
+	msg := &SSEMessage{
+		Event: eventType,
+		Data:  data,
+		ID:    r.lastEventID,
+		Retry: retry,
+	}
+
+	// Dify 通常不发送 event: 行, 尝试从 data JSON 中提取 event 字段
+	if msg.Event == "" && msg.Data != "" {
+		var eventData struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(msg.Data)), &eventData); err == nil && eventData.Event != "" {
+			msg.Event = eventData.Event
+		}
+	}
+	return msg
+}
+
+// scanSSELines 按 CRLF、LF 或 CR 切分行
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if idx := bytes.IndexAny(data, "\r\n"); idx >= 0 {
+		if data[idx] == '\n' {
+			return idx + 1, data[:idx], nil
+		}
+		// CR 之后可能紧跟 LF, 需要更多数据才能判断
+		if idx+1 < len(data) {
+			if data[idx+1] == '\n' {
+				return idx + 2, data[:idx], nil
+			}
+			return idx + 1, data[:idx], nil
+		}
+		if atEOF {
+			return idx + 1, data[:idx], nil
+		}
+		return 0, nil, nil
+	}
+
+	// 末尾不完整的行无法构成事件, 直接丢弃
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// isASCIIDigits 判断是否全部为 ASCII 数字
+func isASCIIDigits(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+package dify
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// readSSEMessages 读取全部事件, 返回读取结束时的错误 (正常结束为 nil)
+func readSSEMessages(r *SSEReader) ([]SSEMessage, error) {
+	var msgs []SSEMessage
+	for {
+		msg, err := r.Read()
+		if err == io.EOF {
+			return msgs, nil
+		}
+		if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, *msg)
+	}
+}
+
+// chunkedReader 每次最多返回 size 个字节, 模拟反向代理重新分块
+type chunkedReader struct {
+	data []byte
+	size int
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := min(len(p), r.size, len(r.data))
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestSSEReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []SSEMessage
+	}{
+		{
+			name:  "lf",
+			input: "data: hello\n\n",
+			want:  []SSEMessage{{Data: "hello"}},
+		},
+		{
+			name:  "crlf",
+			input: "event: ping\r\ndata: hello\r\n\r\n",
+			want:  []SSEMessage{{Event: "ping", Data: "hello"}},
+		},
+		{
+			name:  "cr",
+			input: "event: ping\rdata: hello\r\r",
+			want:  []SSEMessage{{Event: "ping", Data: "hello"}},
+		},
+		{
+			name:  "mixed line endings",
+			input: "data: a\r\n\ndata: b\r\rdata: c\n\r\n",
+			want:  []SSEMessage{{Data: "a"}, {Data: "b"}, {Data: "c"}},
+		},
+		{
+			name:  "bom",
+			input: "\xEF\xBB\xBFdata: hello\n\n",
+			want:  []SSEMessage{{Data: "hello"}},
+		},
+		{
+			name:  "bom only stripped at start",
+			input: "data: a\n\n\xEF\xBB\xBFdata: b\n\n",
+			want:  []SSEMessage{{Data: "a"}},
+		},
+		{
+			name:  "comments",
+			input: ": ping\n\n:keep-alive\ndata: hello\n: inline\n\n",
+			want:  []SSEMessage{{Data: "hello"}},
+		},
+		{
+			name:  "multi-line data",
+			input: "data: line1\ndata: line2\ndata:\n\n",
+			want:  []SSEMessage{{Data: "line1\nline2\n"}},
+		},
+		{
+			name:  "no space after colon",
+			input: "data:hello\ndata:  two spaces\n\n",
+			want:  []SSEMessage{{Data: "hello\n two spaces"}},
+		},
+		{
+			name:  "field without colon",
+			input: "data\n\n",
+			want:  []SSEMessage{{Data: ""}},
+		},
+		{
+			name:  "blank lines without data",
+			input: "\n\nevent: ignored\n\ndata: hello\n\n",
+			want:  []SSEMessage{{Data: "hello"}},
+		},
+		{
+			name:  "unknown fields",
+			input: "foo: bar\ndata: hello\n\n",
+			want:  []SSEMessage{{Data: "hello"}},
+		},
+		{
+			name:  "id persists",
+			input: "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want:  []SSEMessage{{Data: "a", ID: "1"}, {Data: "b", ID: "1"}, {Data: "c"}},
+		},
+		{
+			name:  "id with nul ignored",
+			input: "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			want:  []SSEMessage{{Data: "a", ID: "1"}, {Data: "b", ID: "1"}},
+		},
+		{
+			name:  "retry",
+			input: "retry: 1500\ndata: a\n\nretry: 1.5\ndata: b\n\nretry: -1\ndata: c\n\n",
+			want: []SSEMessage{
+				{Data: "a", Retry: 1500 * time.Millisecond},
+				{Data: "b"},
+				{Data: "c"},
+			},
+		},
+		{
+			name:  "event from json data",
+			input: "data: {\"event\": \"message\", \"answer\": \"hi\"}\n\n",
+			want:  []SSEMessage{{Event: "message", Data: `{"event": "message", "answer": "hi"}`}},
+		},
+		{
+			name:  "event field wins over json",
+			input: "event: ping\ndata: {\"event\": \"message\"}\n\n",
+			want:  []SSEMessage{{Event: "ping", Data: `{"event": "message"}`}},
+		},
+		{
+			name:  "unterminated trailing event",
+			input: "data: a\n\ndata: b",
+			want:  []SSEMessage{{Data: "a"}},
+		},
+		{
+			name:  "trailing event without blank line",
+			input: "data: a\n\ndata: b\n",
+			want:  []SSEMessage{{Data: "a"}},
+		},
+		{
+			name:  "trailing cr",
+			input: "data: a\r\r",
+			want:  []SSEMessage{{Data: "a"}},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	readers := map[string]func(string) io.Reader{
+		"whole":     func(s string) io.Reader { return strings.NewReader(s) },
+		"one byte":  func(s string) io.Reader { return iotest.OneByteReader(strings.NewReader(s)) },
+		"three":     func(s string) io.Reader { return &chunkedReader{data: []byte(s), size: 3} },
+		"half read": func(s string) io.Reader { return iotest.HalfReader(strings.NewReader(s)) },
+	}
+
+	for _, tt := range tests {
+		for name, newReader := range readers {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				got, err := readSSEMessages(NewSSEReader(newReader(tt.input)))
+				if err != nil {
+					t.Fatalf("Read() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("messages = %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSSEReaderLineTooLong(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []SSEMessage
+	}{
+		{
+			name:  "long line",
+			input: "data: a\n\ndata: " + strings.Repeat("x", 64) + "\n\n",
+			want:  []SSEMessage{{Data: "a"}},
+		},
+		{
+			name:  "long line without terminator",
+			input: "data: " + strings.Repeat("x", 64),
+		},
+		{
+			name:  "long event data",
+			input: "data: 0123456789\ndata: 0123456789\ndata: 0123456789\ndata: 0123456789\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSSEMessages(NewSSEReaderSize(strings.NewReader(tt.input), 32))
+			if !errors.Is(err, ErrSSELineTooLong) {
+				t.Fatalf("Read() error = %v, want ErrSSELineTooLong", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messages = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEReaderError(t *testing.T) {
+	readErr := errors.New("connection lost")
+	r := io.MultiReader(strings.NewReader("data: a\n\ndata: b\n"), iotest.ErrReader(readErr))
+
+	got, err := readSSEMessages(NewSSEReader(r))
+	if !errors.Is(err, readErr) {
+		t.Fatalf("Read() error = %v, want %v", err, readErr)
+	}
+	if want := []SSEMessage{{Data: "a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %+v, want %+v", got, want)
+	}
+}
+
+// FuzzSSEReader 校验任意分块方式读取同一输入得到相同的事件
+//
+// 种子语料位于 testdata/fuzz/FuzzSSEReader。
+func FuzzSSEReader(f *testing.F) {
+	f.Add([]byte("data: hello\r\n\r\n"), byte(1))
+	f.Add([]byte("event: ping\rdata: a\rdata: b\r\r"), byte(2))
+
+	f.Fuzz(func(t *testing.T, input []byte, chunk byte) {
+		const maxSize = 256
+
+		want, wantErr := readSSEMessages(NewSSEReaderSize(bytes.NewReader(input), maxSize))
+		got, gotErr := readSSEMessages(NewSSEReaderSize(&chunkedReader{data: input, size: int(chunk%16) + 1}, maxSize))
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("chunked messages = %q, want %q", got, want)
+		}
+		if errors.Is(gotErr, ErrSSELineTooLong) != errors.Is(wantErr, ErrSSELineTooLong) {
+			t.Fatalf("chunked error = %v, want %v", gotErr, wantErr)
+		}
+
+		for _, msg := range want {
+			if len(msg.Data) > maxSize {
+				t.Fatalf("data length = %d, exceeds %d", len(msg.Data), maxSize)
+			}
+		}
+	})
+}
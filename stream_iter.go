@@ -0,0 +1,81 @@
+package dify
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// Events 返回事件迭代器, 可用于 range-over-func
+//
+// 读取出错时产出一次 (nil, err) 后结束; 迭代结束 (包括提前 break) 时自动关闭流。
+//
+//	for event, err := range stream.Events() {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (sr *StreamReader) Events() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		defer sr.Close()
+
+		for {
+			event, err := sr.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Chan 在后台 goroutine 中读取事件并通过通道返回
+//
+// 事件通道在流结束后关闭, 此后可从错误通道读取一次终止错误 (正常结束时为 nil)。
+// ctx 取消时会关闭流以中断阻塞的读取, 错误通道返回 ctx.Err()。
+// 流在读取结束后自动关闭, 调用方无需再调用 Close。
+func (sr *StreamReader) Chan(ctx context.Context) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errc := make(chan error, 1)
+
+	stop := context.AfterFunc(ctx, func() {
+		sr.Close()
+	})
+
+	go func() {
+		defer close(errc)
+		defer close(events)
+		defer stop()
+		defer sr.Close()
+
+		for {
+			event, err := sr.Next()
+			if err != nil {
+				if ctx.Err() != nil {
+					errc <- ctx.Err()
+				} else if err != io.EOF {
+					errc <- err
+				} else {
+					errc <- nil
+				}
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return events, errc
+}
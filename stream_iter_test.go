@@ -0,0 +1,159 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// trackingBody 记录响应体是否已关闭
+type trackingBody struct {
+	io.Reader
+	closed atomic.Int32
+}
+
+func (b *trackingBody) Close() error {
+	b.closed.Add(1)
+	return nil
+}
+
+// messageFixture 生成 n 个 message 事件
+func messageFixture(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, "data: {\"event\":\"message\",\"message_id\":\"m\",\"answer\":\"%d\"}\n\n", i)
+	}
+	return sb.String()
+}
+
+func TestStreamEventsBreakClosesBody(t *testing.T) {
+	body := &trackingBody{Reader: strings.NewReader(messageFixture(5))}
+	stream := NewStreamReader(&http.Response{Body: body})
+
+	var seen int
+	for event, err := range stream.Events() {
+		if err != nil {
+			t.Fatalf("Events() error = %v", err)
+		}
+		if _, ok := event.(*MessageStreamEvent); !ok {
+			t.Fatalf("event = %#v, want *MessageStreamEvent", event)
+		}
+		seen++
+		if seen == 2 {
+			break
+		}
+	}
+
+	if seen != 2 {
+		t.Errorf("events = %d, want 2", seen)
+	}
+	if body.closed.Load() != 1 {
+		t.Errorf("body closed %d times, want 1", body.closed.Load())
+	}
+}
+
+func TestStreamEventsError(t *testing.T) {
+	body := &trackingBody{Reader: strings.NewReader(messageFixture(1) + errorStreamFixture)}
+	stream := NewStreamReader(&http.Response{Body: body})
+
+	var (
+		events int
+		errs   []error
+	)
+	for event, err := range stream.Events() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if event == nil {
+			t.Error("event = nil without error")
+		}
+		events++
+	}
+
+	var streamErr *StreamError
+	if len(errs) != 1 || !errors.As(errs[0], &streamErr) {
+		t.Errorf("errors = %v, want one *StreamError", errs)
+	}
+	if events != 2 {
+		t.Errorf("events = %d, want 2", events)
+	}
+	if body.closed.Load() != 1 {
+		t.Errorf("body closed %d times, want 1", body.closed.Load())
+	}
+}
+
+func TestStreamChan(t *testing.T) {
+	body := &trackingBody{Reader: strings.NewReader(messageFixture(3))}
+	stream := NewStreamReader(&http.Response{Body: body})
+
+	events, errc := stream.Chan(context.Background())
+	var seen int
+	for range events {
+		seen++
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Chan() error = %v, want nil", err)
+	}
+	if seen != 3 {
+		t.Errorf("events = %d, want 3", seen)
+	}
+	if body.closed.Load() == 0 {
+		t.Error("body not closed after stream end")
+	}
+}
+
+func TestStreamChanCancel(t *testing.T) {
+	// 服务端发送一个事件后不再发送, 读取会一直阻塞, 直到流被关闭
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, messageFixture(1))
+	}()
+	stream := NewStreamReader(&http.Response{Body: pr})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errc := stream.Chan(ctx)
+
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for first event")
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range events {
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("events channel not closed after cancel")
+	}
+
+	select {
+	case err, ok := <-errc:
+		if !ok || !errors.Is(err, context.Canceled) {
+			t.Errorf("Chan() error = %v (ok %v), want context.Canceled", err, ok)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+	if _, ok := <-errc; ok {
+		t.Error("error channel not closed")
+	}
+
+	// 读取协程退出前关闭了流, 写端因此返回错误
+	if _, err := pw.Write([]byte("data: {}\n\n")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after cancel error = %v, want io.ErrClosedPipe", err)
+	}
+}
@@ -0,0 +1,116 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// errorStreamFixture 在输出部分内容后返回 error 事件的流
+const errorStreamFixture = "" +
+	"data: {\"event\":\"message\",\"task_id\":\"t\",\"message_id\":\"m\",\"answer\":\"partial\"}\n\n" +
+	"data: {\"event\":\"error\",\"task_id\":\"t\",\"message_id\":\"m\",\"status\":400,\"code\":\"provider_quota_exceeded\",\"message\":\"quota exceeded\"}\n\n" +
+	"data: {\"event\":\"message_end\",\"task_id\":\"t\",\"message_id\":\"m\"}\n\n"
+
+func TestStreamReaderErrorEvent(t *testing.T) {
+	events, err := readEvents(newTestStream(errorStreamFixture))
+
+	if len(events) != 1 {
+		t.Errorf("events before error = %d, want 1", len(events))
+	}
+
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) {
+		t.Fatalf("error = %v, want *StreamError", err)
+	}
+	if streamErr.TaskID != "t" || streamErr.MessageID != "m" || streamErr.Status != 400 || streamErr.Code != ErrCodeProviderQuotaExceeded {
+		t.Errorf("StreamError = %+v", streamErr)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("errors.As(*APIError) = false for %v", err)
+	}
+	if apiErr.StatusCode != 400 || apiErr.Code != ErrCodeProviderQuotaExceeded || apiErr.Message != "quota exceeded" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("errors.Is(%v, ErrQuotaExceeded) = false", err)
+	}
+}
+
+func TestStreamReaderErrorPassthrough(t *testing.T) {
+	stream := newTestStream(errorStreamFixture)
+	stream.SetErrorPassthrough(true)
+
+	events, err := readEvents(stream)
+	if err != nil {
+		t.Fatalf("Next() error = %v, want error event passed through", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %d, want 3", len(events))
+	}
+	e, ok := events[1].(*ErrorStreamEvent)
+	if !ok || e.Code != ErrCodeProviderQuotaExceeded || e.Status != 400 {
+		t.Errorf("event 1 = %#v, want *ErrorStreamEvent", events[1])
+	}
+
+	// 透传时由累加器将 error 事件转换为 *StreamError
+	stream = newTestStream(errorStreamFixture)
+	stream.SetErrorPassthrough(true)
+	_, err = AccumulateChat(stream, nil)
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) || streamErr.Code != ErrCodeProviderQuotaExceeded {
+		t.Errorf("AccumulateChat() error = %v, want *StreamError", err)
+	}
+}
+
+func TestClientStreamErrorPassthrough(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, errorStreamFixture)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		passthrough bool
+		wantErr     bool
+	}{
+		{passthrough: false, wantErr: true},
+		{passthrough: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("passthrough=%v", tt.passthrough), func(t *testing.T) {
+			client, err := NewChatClient(ClientConfig{
+				APIKey:                 "test-key",
+				BaseURL:                server.URL,
+				StreamErrorPassthrough: tt.passthrough,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stream, err := client.SendMessageStream(context.Background(), &ChatRequest{Query: "hi", User: "u"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+
+			events, err := readEvents(stream)
+			var streamErr *StreamError
+			if tt.wantErr {
+				if !errors.As(err, &streamErr) || len(events) != 1 {
+					t.Errorf("events = %d, error = %v, want *StreamError after 1 event", len(events), err)
+				}
+				return
+			}
+			if err != nil || len(events) != 3 {
+				t.Errorf("events = %d, error = %v, want 3 events", len(events), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package dify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExecutionTrace 工作流执行轨迹, 由流式节点事件组装而成
+type ExecutionTrace struct {
+	WorkflowRunID string           `json:"workflow_run_id"`
+	WorkflowID    string           `json:"workflow_id"`
+	TaskID        string           `json:"task_id"`
+	Status        string           `json:"status"`
+	Error         string           `json:"error,omitempty"`
+	ElapsedTime   float64          `json:"elapsed_time"`
+	TotalTokens   int              `json:"total_tokens"`
+	CreatedAt     int64            `json:"created_at"`
+	FinishedAt    int64            `json:"finished_at"`
+	Nodes         []*NodeExecution `json:"nodes"`
+	Edges         []TraceEdge      `json:"edges"`
+}
+
+// NodeExecution 单次节点执行
+type NodeExecution struct {
+	ID                string                 `json:"id"`
+	NodeID            string                 `json:"node_id"`
+	NodeType          string                 `json:"node_type"`
+	Title             string                 `json:"title"`
+	Index             int                    `json:"index"`
+	PredecessorNodeID string                 `json:"predecessor_node_id,omitempty"`
+	ParentID          string                 `json:"parent_id,omitempty"` // 所属迭代/循环节点的执行 ID
+	Round             int                    `json:"round,omitempty"`     // 在所属迭代/循环中的轮次 (从 0 开始)
+	Status            string                 `json:"status"`
+	Error             string                 `json:"error,omitempty"`
+	Inputs            map[string]interface{} `json:"inputs,omitempty"`
+	ProcessData       map[string]interface{} `json:"process_data,omitempty"`
+	Outputs           map[string]interface{} `json:"outputs,omitempty"`
+	ElapsedTime       float64                `json:"elapsed_time"`
+	TotalTokens       int                    `json:"total_tokens"`
+	TotalPrice        string                 `json:"total_price,omitempty"`
+	Currency          string                 `json:"currency,omitempty"`
+	Retries           int                    `json:"retries,omitempty"`
+	CreatedAt         int64                  `json:"created_at"`
+	FinishedAt        int64                  `json:"finished_at,omitempty"`
+	ExecutionMetadata map[string]interface{} `json:"execution_metadata,omitempty"`
+	NodeScope
+}
+
+// TraceEdge 节点执行之间的边, From/To 为 NodeExecution.ID
+type TraceEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TraceBuilder 从工作流流式事件组装执行轨迹
+//
+// 可直接作为 EventHandler 传给 AccumulateWorkflow / AccumulateChat:
+//
+//	builder := dify.NewTraceBuilder()
+//	resp, err := dify.AccumulateWorkflow(stream, builder.Handle)
+//	fmt.Println(builder.Trace().Mermaid())
+type TraceBuilder struct {
+	trace  ExecutionTrace
+	byID   map[string]*NodeExecution
+	latest map[string]*NodeExecution // node_id -> 最近一次执行
+	rounds map[string]int            // 迭代/循环的 node_id -> 当前轮次
+}
+
+// NewTraceBuilder 创建执行轨迹构建器
+func NewTraceBuilder() *TraceBuilder {
+	return &TraceBuilder{
+		byID:   make(map[string]*NodeExecution),
+		latest: make(map[string]*NodeExecution),
+		rounds: make(map[string]int),
+	}
+}
+
+// Handle 处理单个事件, 忽略与工作流节点无关的事件
+func (b *TraceBuilder) Handle(event Event) error {
+	switch e := event.(type) {
+	case *WorkflowStartedEvent:
+		b.trace.WorkflowRunID = e.WorkflowRunID
+		b.trace.TaskID = e.TaskID
+		b.trace.WorkflowID = e.Data.WorkflowID
+		b.trace.CreatedAt = e.Data.CreatedAt
+		b.trace.Status = WorkflowStatusRunning
+	case *NodeStartedEvent:
+		b.nodeStarted(&e.Data)
+	case *NodeFinishedEvent:
+		b.nodeFinished(&e.Data)
+	case *IterationStartedEvent:
+		b.containerStarted(&e.Data)
+	case *LoopStartedEvent:
+		b.containerStarted(&e.Data)
+	case *IterationNextEvent:
+		b.rounds[e.Data.NodeID] = e.Data.Index
+	case *LoopNextEvent:
+		b.rounds[e.Data.NodeID] = e.Data.Index
+	case *IterationCompletedEvent:
+		b.containerFinished(&e.Data)
+	case *LoopCompletedEvent:
+		b.containerFinished(&e.Data)
+	case *NodeRetryEvent:
+		if node := b.latest[e.Data.NodeID]; node != nil {
+			node.Retries++
+		}
+	case *WorkflowFinishedEvent:
+		b.trace.WorkflowRunID = e.WorkflowRunID
+		b.trace.TaskID = e.TaskID
+		if e.Data.WorkflowID != "" {
+			b.trace.WorkflowID = e.Data.WorkflowID
+		}
+		b.trace.Status = e.Data.Status
+		b.trace.Error = e.Data.Error
+		b.trace.ElapsedTime = e.Data.ElapsedTime
+		b.trace.TotalTokens = e.Data.TotalTokens
+		b.trace.FinishedAt = e.Data.FinishedAt
+	}
+	return nil
+}
+
+// nodeStarted 记录节点开始
+func (b *TraceBuilder) nodeStarted(d *NodeStartedData) {
+	node := b.byID[d.ID]
+	if node == nil {
+		node = &NodeExecution{ID: d.ID}
+		b.byID[d.ID] = node
+		b.trace.Nodes = append(b.trace.Nodes, node)
+		b.link(node, d.PredecessorNodeID, d.NodeScope)
+	}
+
+	node.NodeID = d.NodeID
+	node.NodeType = d.NodeType
+	node.Title = d.Title
+	node.Index = d.Index
+	node.PredecessorNodeID = d.PredecessorNodeID
+	node.Inputs = d.Inputs
+	node.CreatedAt = d.CreatedAt
+	node.NodeScope = d.NodeScope
+	node.Status = WorkflowStatusRunning
+	node.Round = b.rounds[d.ContainerID()]
+	b.latest[d.NodeID] = node
+}
+
+// nodeFinished 记录节点完成
+func (b *TraceBuilder) nodeFinished(d *NodeFinishedData) {
+	node := b.byID[d.ID]
+	if node == nil {
+		// 未收到 node_started 时按完成事件补齐
+		b.nodeStarted(&NodeStartedData{
+			ID:                d.ID,
+			NodeID:            d.NodeID,
+			NodeType:          d.NodeType,
+			Title:             d.Title,
+			Index:             d.Index,
+			PredecessorNodeID: d.PredecessorNodeID,
+			Inputs:            d.Inputs,
+			CreatedAt:         d.CreatedAt,
+			NodeScope:         d.NodeScope,
+		})
+		node = b.byID[d.ID]
+	}
+
+	node.Status = d.Status
+	node.Error = d.Error
+	node.ProcessData = d.ProcessData
+	node.Outputs = d.Outputs
+	node.ElapsedTime = d.ElapsedTime
+	node.FinishedAt = d.FinishedAt
+	node.ExecutionMetadata = d.ExecutionMetadata
+	if d.Inputs != nil {
+		node.Inputs = d.Inputs
+	}
+	if tokens, ok := metadataInt(d.ExecutionMetadata, "total_tokens"); ok {
+		node.TotalTokens = tokens
+	}
+	node.TotalPrice = metadataString(d.ExecutionMetadata, "total_price")
+	node.Currency = metadataString(d.ExecutionMetadata, "currency")
+
+	// 并行迭代中多轮交错执行, 以完成事件携带的轮次为准
+	if round, ok := d.Round(); ok {
+		node.Round = round
+	}
+}
+
+// containerStarted 记录迭代/循环开始
+//
+// 容器节点通常已由 node_started 记录, 此时只重置轮次。
+func (b *TraceBuilder) containerStarted(d *IterationStartedData) {
+	// 执行 ID 不一致时沿用该节点进行中的执行
+	latest := b.latest[d.NodeID]
+	if b.byID[d.ID] == nil && (latest == nil || latest.Status != WorkflowStatusRunning) {
+		b.nodeStarted(&NodeStartedData{
+			ID:        d.ID,
+			NodeID:    d.NodeID,
+			NodeType:  d.NodeType,
+			Title:     d.Title,
+			Inputs:    d.Inputs,
+			CreatedAt: d.CreatedAt,
+			NodeScope: NodeScope{
+				ParallelID:          d.ParallelID,
+				ParallelStartNodeID: d.ParallelStartNodeID,
+			},
+		})
+	}
+	b.rounds[d.NodeID] = 0
+}
+
+// containerFinished 记录迭代/循环完成, 已由 node_finished 记录时忽略
+func (b *TraceBuilder) containerFinished(d *IterationCompletedData) {
+	delete(b.rounds, d.NodeID)
+
+	id := d.ID
+	node := b.byID[d.ID]
+	if node == nil {
+		node = b.latest[d.NodeID]
+	}
+	if node != nil {
+		if node.Status != WorkflowStatusRunning {
+			return
+		}
+		id = node.ID
+	}
+
+	b.nodeFinished(&NodeFinishedData{
+		ID:                id,
+		NodeID:            d.NodeID,
+		NodeType:          d.NodeType,
+		Title:             d.Title,
+		Inputs:            d.Inputs,
+		Outputs:           d.Outputs,
+		Status:            d.Status,
+		Error:             d.Error,
+		ElapsedTime:       d.ElapsedTime,
+		ExecutionMetadata: d.ExecutionMetadata,
+		CreatedAt:         d.CreatedAt,
+		FinishedAt:        d.FinishedAt,
+	})
+	if node := b.byID[id]; node.TotalTokens == 0 {
+		node.TotalTokens = d.TotalTokens
+	}
+}
+
+// link 根据前驱节点与所属迭代/循环建立边
+func (b *TraceBuilder) link(node *NodeExecution, predecessorNodeID string, scope NodeScope) {
+	if container := scope.ContainerID(); container != "" {
+		if parent := b.latest[container]; parent != nil {
+			node.ParentID = parent.ID
+		}
+	}
+
+	if predecessorNodeID != "" {
+		if pred := b.latest[predecessorNodeID]; pred != nil {
+			b.trace.Edges = append(b.trace.Edges, TraceEdge{From: pred.ID, To: node.ID})
+			return
+		}
+	}
+	// 迭代/循环内的首个节点连接到容器节点
+	if node.ParentID != "" {
+		b.trace.Edges = append(b.trace.Edges, TraceEdge{From: node.ParentID, To: node.ID})
+	}
+}
+
+// Trace 返回当前的执行轨迹
+func (b *TraceBuilder) Trace() *ExecutionTrace {
+	return &b.trace
+}
+
+// JSON 导出为缩进格式的 JSON
+func (t *ExecutionTrace) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// Mermaid 导出为 Mermaid flowchart, 迭代/循环内的节点放在子图中
+func (t *ExecutionTrace) Mermaid() string {
+	ids := t.shortIDs()
+	children := t.children()
+
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+
+	var writeNode func(node *NodeExecution, depth int)
+	writeNode = func(node *NodeExecution, depth int) {
+		pad := strings.Repeat("    ", depth)
+		fmt.Fprintf(&sb, "%s%s[\"%s\"]\n", pad, ids[node.ID], mermaidEscape(node.label("<br/>")))
+		if kids := children[node.ID]; len(kids) > 0 {
+			fmt.Fprintf(&sb, "%ssubgraph %s_body[\"%s\"]\n", pad, ids[node.ID], mermaidEscape(node.Title))
+			for _, child := range kids {
+				writeNode(child, depth+1)
+			}
+			fmt.Fprintf(&sb, "%send\n", pad)
+		}
+		if node.Status == WorkflowStatusFailed || node.Status == "exception" {
+			fmt.Fprintf(&sb, "%sstyle %s stroke:#d33,stroke-width:2px\n", pad, ids[node.ID])
+		}
+	}
+	for _, node := range t.Nodes {
+		if node.ParentID == "" || ids[node.ParentID] == "" {
+			writeNode(node, 1)
+		}
+	}
+
+	for _, edge := range t.Edges {
+		fmt.Fprintf(&sb, "    %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+	return sb.String()
+}
+
+// DOT 导出为 Graphviz DOT, 迭代/循环内的节点放在 cluster 中
+func (t *ExecutionTrace) DOT() string {
+	ids := t.shortIDs()
+	children := t.children()
+
+	var sb strings.Builder
+	sb.WriteString("digraph workflow {\n")
+	sb.WriteString("    rankdir=TB;\n")
+	sb.WriteString("    node [shape=box, style=rounded];\n")
+
+	var writeNode func(node *NodeExecution, depth int)
+	writeNode = func(node *NodeExecution, depth int) {
+		pad := strings.Repeat("    ", depth)
+		color := "black"
+		switch node.Status {
+		case WorkflowStatusSucceeded:
+			color = "darkgreen"
+		case WorkflowStatusFailed, "exception":
+			color = "red"
+		}
+		fmt.Fprintf(&sb, "%s%s [label=%s, color=%s];\n", pad, ids[node.ID], strconv.Quote(node.label("\n")), color)
+
+		if kids := children[node.ID]; len(kids) > 0 {
+			fmt.Fprintf(&sb, "%ssubgraph cluster_%s {\n", pad, ids[node.ID])
+			fmt.Fprintf(&sb, "%s    label=%s;\n", pad, strconv.Quote(node.Title))
+			for _, child := range kids {
+				writeNode(child, depth+1)
+			}
+			fmt.Fprintf(&sb, "%s}\n", pad)
+		}
+	}
+	for _, node := range t.Nodes {
+		if node.ParentID == "" || ids[node.ParentID] == "" {
+			writeNode(node, 1)
+		}
+	}
+
+	for _, edge := range t.Edges {
+		fmt.Fprintf(&sb, "    %s -> %s;\n", ids[edge.From], ids[edge.To])
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// shortIDs 为节点执行分配简短的图节点 ID
+func (t *ExecutionTrace) shortIDs() map[string]string {
+	ids := make(map[string]string, len(t.Nodes))
+	for i, node := range t.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i+1)
+	}
+	return ids
+}
+
+// children 按 ParentID 分组子节点
+func (t *ExecutionTrace) children() map[string][]*NodeExecution {
+	children := make(map[string][]*NodeExecution)
+	for _, node := range t.Nodes {
+		if node.ParentID != "" {
+			children[node.ParentID] = append(children[node.ParentID], node)
+		}
+	}
+	return children
+}
+
+// label 图中显示的节点标签
+func (n *NodeExecution) label(sep string) string {
+	parts := []string{n.Title, fmt.Sprintf("%s · %s · %.2fs", n.NodeType, n.Status, n.ElapsedTime)}
+	if n.ParentID != "" {
+		parts = append(parts, fmt.Sprintf("round %d", n.Round))
+	}
+	if n.TotalTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d tokens", n.TotalTokens))
+	}
+	if n.Retries > 0 {
+		parts = append(parts, fmt.Sprintf("%d retries", n.Retries))
+	}
+	if n.Error != "" {
+		parts = append(parts, "error: "+truncate(n.Error, 60))
+	}
+	return strings.Join(parts, sep)
+}
+
+// metadataString 从 execution_metadata 读取字符串字段
+func metadataString(metadata map[string]interface{}, key string) string {
+	switch v := metadata[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// mermaidEscape 转义 Mermaid 标签中的双引号与换行
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
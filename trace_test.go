@@ -0,0 +1,92 @@
+package dify
+
+import (
+	"strings"
+	"testing"
+)
+
+// handleTraceEvents 按顺序将 SSE 事件交给 TraceBuilder
+func handleTraceEvents(t *testing.T, b *TraceBuilder, events [][2]string) {
+	t.Helper()
+	for _, e := range events {
+		event, err := DecodeEvent(&SSEMessage{Event: e[0], Data: e[1]})
+		if err != nil {
+			t.Fatalf("DecodeEvent(%s) error = %v", e[0], err)
+		}
+		if err := b.Handle(event); err != nil {
+			t.Fatalf("Handle(%s) error = %v", e[0], err)
+		}
+	}
+}
+
+func TestTraceBuilderRounds(t *testing.T) {
+	b := NewTraceBuilder()
+	handleTraceEvents(t, b, [][2]string{
+		{EventWorkflowStarted, `{"workflow_run_id":"run","data":{"workflow_id":"wf"}}`},
+		{EventNodeStarted, `{"data":{"id":"e-start","node_id":"start","node_type":"start","title":"Start"}}`},
+		{EventNodeFinished, `{"data":{"id":"e-start","node_id":"start","status":"succeeded"}}`},
+
+		// 并行迭代: 第 1 轮在第 0 轮完成前开始
+		{EventNodeStarted, `{"data":{"id":"e-it","node_id":"it","node_type":"iteration","title":"Iterate","predecessor_node_id":"start"}}`},
+		{EventIterationStarted, `{"data":{"id":"e-it","node_id":"it","node_type":"iteration","title":"Iterate"}}`},
+		{EventIterationNext, `{"data":{"id":"e-it","node_id":"it","index":0}}`},
+		{EventNodeStarted, `{"data":{"id":"e-llm-0","node_id":"llm","node_type":"llm","title":"LLM","iteration_id":"it"}}`},
+		{EventIterationNext, `{"data":{"id":"e-it","node_id":"it","index":1}}`},
+		{EventNodeStarted, `{"data":{"id":"e-llm-1","node_id":"llm","node_type":"llm","title":"LLM","iteration_id":"it"}}`},
+		{EventNodeFinished, `{"data":{"id":"e-llm-1","node_id":"llm","status":"succeeded","iteration_id":"it","execution_metadata":{"iteration_index":1,"total_tokens":10}}}`},
+		{EventNodeFinished, `{"data":{"id":"e-llm-0","node_id":"llm","status":"succeeded","iteration_id":"it","execution_metadata":{"iteration_index":0,"total_tokens":20}}}`},
+		{EventIterationCompleted, `{"data":{"id":"e-it","node_id":"it","status":"succeeded","total_tokens":30}}`},
+
+		// 未发送 node_started 的循环, 轮次来自 loop_next
+		{EventLoopStarted, `{"data":{"id":"e-loop","node_id":"loop","node_type":"loop","title":"Loop"}}`},
+		{EventLoopNext, `{"data":{"id":"e-loop","node_id":"loop","index":0}}`},
+		{EventNodeStarted, `{"data":{"id":"e-body-0","node_id":"body","node_type":"code","title":"Body","loop_id":"loop"}}`},
+		{EventNodeFinished, `{"data":{"id":"e-body-0","node_id":"body","status":"succeeded","loop_id":"loop"}}`},
+		{EventLoopNext, `{"data":{"id":"e-loop","node_id":"loop","index":1}}`},
+		{EventNodeStarted, `{"data":{"id":"e-body-1","node_id":"body","node_type":"code","title":"Body","loop_id":"loop"}}`},
+		{EventNodeFinished, `{"data":{"id":"e-body-1","node_id":"body","status":"failed","error":"boom","loop_id":"loop"}}`},
+		{EventLoopCompleted, `{"data":{"id":"e-loop","node_id":"loop","status":"failed","error":"boom"}}`},
+		{EventWorkflowFinished, `{"workflow_run_id":"run","data":{"status":"failed"}}`},
+	})
+
+	trace := b.Trace()
+	nodes := make(map[string]*NodeExecution)
+	for _, node := range trace.Nodes {
+		nodes[node.ID] = node
+	}
+	if len(nodes) != len(trace.Nodes) || len(nodes) != 7 {
+		t.Fatalf("nodes = %d unique of %d, want 7", len(nodes), len(trace.Nodes))
+	}
+
+	tests := []struct {
+		id       string
+		parentID string
+		round    int
+		status   string
+		tokens   int
+	}{
+		{id: "e-start", status: "succeeded"},
+		{id: "e-it", status: "succeeded", tokens: 30},
+		{id: "e-llm-0", parentID: "e-it", round: 0, status: "succeeded", tokens: 20},
+		{id: "e-llm-1", parentID: "e-it", round: 1, status: "succeeded", tokens: 10},
+		{id: "e-loop", status: "failed"},
+		{id: "e-body-0", parentID: "e-loop", round: 0, status: "succeeded"},
+		{id: "e-body-1", parentID: "e-loop", round: 1, status: "failed"},
+	}
+	for _, tt := range tests {
+		node := nodes[tt.id]
+		if node == nil {
+			t.Errorf("node %s missing", tt.id)
+			continue
+		}
+		if node.ParentID != tt.parentID || node.Round != tt.round || node.Status != tt.status || node.TotalTokens != tt.tokens {
+			t.Errorf("node %s = parent %q round %d status %q tokens %d, want parent %q round %d status %q tokens %d",
+				tt.id, node.ParentID, node.Round, node.Status, node.TotalTokens,
+				tt.parentID, tt.round, tt.status, tt.tokens)
+		}
+	}
+
+	if !strings.Contains(trace.Mermaid(), "round 1") {
+		t.Errorf("Mermaid() missing round label:\n%s", trace.Mermaid())
+	}
+}
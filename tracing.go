@@ -0,0 +1,283 @@
+package dify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Tracer 分布式追踪接口
+//
+// SDK 本身不依赖任何追踪库, OpenTelemetry 的实现位于 github.com/Angbro/dify-go/otel。
+type Tracer interface {
+	// StartSpan 开始一个 span, 返回携带该 span 的 ctx
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+	// Inject 将 ctx 中的追踪上下文写入请求头 (如 W3C traceparent)
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span 追踪中的单个操作
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute span 属性, Value 为 string、bool、int、int64 或 float64
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// span 属性名
+const (
+	AttrHTTPMethod      = "http.request.method"
+	AttrHTTPStatusCode  = "http.response.status_code"
+	AttrURLPath         = "url.path"
+	AttrErrorCode       = "dify.error_code"
+	AttrRetryCount      = "dify.retry_count"
+	AttrWorkflowRunID   = "dify.workflow_run_id"
+	AttrNodeID          = "dify.node.id"
+	AttrNodeExecutionID = "dify.node.execution_id"
+	AttrNodeType        = "dify.node.type"
+	AttrNodeTitle       = "dify.node.title"
+	AttrNodeIndex       = "dify.node.index"
+	AttrNodeStatus      = "dify.node.status"
+	AttrNodeElapsedTime = "dify.node.elapsed_time"
+	AttrNodeTotalTokens = "dify.node.total_tokens"
+	AttrNodeTotalPrice  = "dify.node.total_price"
+)
+
+// traceRequest 为一次 API 调用 (含重试) 创建 span
+//
+// 成功返回响应时, span 在响应体关闭时结束, 因此流式请求的 span 覆盖整个流。
+func (c *Client) traceRequest(ctx context.Context, method, path string, fn func(ctx context.Context) (*http.Response, int, error)) (*http.Response, error) {
+	urlPath, _, _ := strings.Cut(path, "?")
+	ctx, span := c.tracer.StartSpan(ctx, method,
+		Attribute{Key: AttrHTTPMethod, Value: method},
+		Attribute{Key: AttrURLPath, Value: urlPath},
+	)
+
+	resp, attempts, err := fn(ctx)
+	span.SetAttributes(Attribute{Key: AttrRetryCount, Value: max(attempts-1, 0)})
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+
+	span.SetAttributes(Attribute{Key: AttrHTTPStatusCode, Value: resp.StatusCode})
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+		apiErr := ParseAPIError(resp.StatusCode, respBody)
+		var e *APIError
+		if errors.As(apiErr, &e) {
+			span.SetAttributes(Attribute{Key: AttrErrorCode, Value: e.Code})
+		}
+		span.RecordError(apiErr)
+	}
+
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody 在响应体关闭时结束 span
+type spanBody struct {
+	io.ReadCloser
+	span Span
+	once sync.Once
+}
+
+func (b *spanBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.span.End)
+	return err
+}
+
+// nodeTracer 根据流中的节点事件创建子 span
+//
+// Chan 在 ctx 取消时会从其他 goroutine 调用 Close, 因此所有字段由 mu 保护。
+type nodeTracer struct {
+	tracer Tracer
+	ctx    context.Context
+
+	mu         sync.Mutex
+	spans      map[string]nodeSpan // 执行 ID -> 进行中的节点 span
+	containers map[string]string   // 迭代/循环的 node_id -> 执行 ID
+	closed     bool
+}
+
+// newNodeTracer 创建节点追踪器, ctx 为请求 span 所在的上下文
+func newNodeTracer(tracer Tracer, ctx context.Context) *nodeTracer {
+	return &nodeTracer{
+		tracer:     tracer,
+		ctx:        ctx,
+		spans:      make(map[string]nodeSpan),
+		containers: make(map[string]string),
+	}
+}
+
+// nodeSpan 进行中的节点 span
+type nodeSpan struct {
+	ctx  context.Context
+	span Span
+}
+
+// nodeTraceEvent 节点事件中与追踪相关的字段
+type nodeTraceEvent struct {
+	WorkflowRunID string        `json:"workflow_run_id"`
+	Data          nodeTraceData `json:"data"`
+}
+
+// nodeTraceData 节点及迭代/循环事件的数据
+type nodeTraceData struct {
+	NodeFinishedData
+	TotalTokens int `json:"total_tokens"` // iteration_completed / loop_completed
+}
+
+// observe 处理单个 SSE 消息
+//
+// 节点 span 按执行 ID 记录, 并行迭代中同一节点的多轮执行互不干扰;
+// 迭代与循环另按 node_id 记录, 其内部节点的 span 挂在容器节点的 span 下。
+func (t *nodeTracer) observe(msg *SSEMessage) {
+	var started, container bool
+	switch msg.Event {
+	case EventNodeStarted:
+		started = true
+	case EventIterationStarted, EventLoopStarted:
+		started, container = true, true
+	case EventNodeFinished:
+	case EventIterationCompleted, EventLoopCompleted:
+		container = true
+	default:
+		return
+	}
+
+	var e nodeTraceEvent
+	if err := json.Unmarshal([]byte(msg.Data), &e); err != nil {
+		return
+	}
+	d := &e.Data
+	if d.ID == "" {
+		d.ID = d.NodeID
+	}
+	if d.NodeType == "iteration" || d.NodeType == "loop" {
+		container = true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+
+	if started {
+		t.start(e.WorkflowRunID, d, container)
+	} else {
+		t.finish(d, container)
+	}
+}
+
+// start 开始节点 span, 调用方需持有 mu
+func (t *nodeTracer) start(workflowRunID string, d *nodeTraceData, container bool) {
+	// 迭代/循环节点会先后收到 node_started 与 iteration_started
+	if _, ok := t.spans[d.ID]; ok {
+		if container {
+			t.containers[d.NodeID] = d.ID
+		}
+		return
+	}
+	if container {
+		if id, ok := t.containers[d.NodeID]; ok {
+			if _, running := t.spans[id]; running {
+				return
+			}
+		}
+	}
+
+	parent := t.ctx
+	if ns, ok := t.container(d.IterationID); ok {
+		parent = ns.ctx
+	} else if ns, ok := t.container(d.LoopID); ok {
+		parent = ns.ctx
+	}
+
+	ctx, span := t.tracer.StartSpan(parent, fmt.Sprintf("dify.node %s", d.Title),
+		Attribute{Key: AttrWorkflowRunID, Value: workflowRunID},
+		Attribute{Key: AttrNodeID, Value: d.NodeID},
+		Attribute{Key: AttrNodeExecutionID, Value: d.ID},
+		Attribute{Key: AttrNodeType, Value: d.NodeType},
+		Attribute{Key: AttrNodeTitle, Value: d.Title},
+		Attribute{Key: AttrNodeIndex, Value: d.Index},
+	)
+	t.spans[d.ID] = nodeSpan{ctx: ctx, span: span}
+	if container {
+		t.containers[d.NodeID] = d.ID
+	}
+}
+
+// finish 结束节点 span, 调用方需持有 mu
+func (t *nodeTracer) finish(d *nodeTraceData, container bool) {
+	id := d.ID
+	ns, ok := t.spans[id]
+	if !ok && container {
+		// 执行 ID 不一致时按 node_id 查找容器节点
+		id = t.containers[d.NodeID]
+		ns, ok = t.spans[id]
+	}
+	if !ok {
+		return
+	}
+	delete(t.spans, id)
+	if container && t.containers[d.NodeID] == id {
+		delete(t.containers, d.NodeID)
+	}
+
+	ns.span.SetAttributes(
+		Attribute{Key: AttrNodeStatus, Value: d.Status},
+		Attribute{Key: AttrNodeElapsedTime, Value: d.ElapsedTime},
+	)
+	if tokens, ok := metadataInt(d.ExecutionMetadata, "total_tokens"); ok {
+		ns.span.SetAttributes(Attribute{Key: AttrNodeTotalTokens, Value: tokens})
+	} else if d.TotalTokens > 0 {
+		ns.span.SetAttributes(Attribute{Key: AttrNodeTotalTokens, Value: d.TotalTokens})
+	}
+	if price := metadataString(d.ExecutionMetadata, "total_price"); price != "" {
+		ns.span.SetAttributes(Attribute{Key: AttrNodeTotalPrice, Value: price})
+	}
+	if d.Error != "" {
+		ns.span.RecordError(errors.New(d.Error))
+	}
+	ns.span.End()
+}
+
+// container 返回迭代/循环节点进行中的 span, 调用方需持有 mu
+func (t *nodeTracer) container(nodeID string) (nodeSpan, bool) {
+	if nodeID == "" {
+		return nodeSpan{}, false
+	}
+	ns, ok := t.spans[t.containers[nodeID]]
+	return ns, ok
+}
+
+// close 结束所有未完成的节点 span, 之后的事件不再创建 span
+func (t *nodeTracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	for id, ns := range t.spans {
+		ns.span.End()
+		delete(t.spans, id)
+	}
+	clear(t.containers)
+}
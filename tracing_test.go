@@ -0,0 +1,275 @@
+package dify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTracer 记录 span 的测试 Tracer, 可并发使用
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpanKey struct{}
+
+// recordedSpan 记录的 span
+type recordedSpan struct {
+	tracer *recordingTracer
+	id     int
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	errs   []error
+	ended  int
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parent, _ := ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	span := &recordedSpan{
+		tracer: t,
+		id:     len(t.spans) + 1,
+		name:   name,
+		parent: parent,
+		attrs:  make(map[string]interface{}),
+	}
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	if span, ok := ctx.Value(recordedSpanKey{}).(*recordedSpan); ok {
+		header.Set("traceparent", fmt.Sprintf("span-%d", span.id))
+	}
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *recordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.ended++
+}
+
+// byAttr 按属性值查找 span
+func (t *recordingTracer) byAttr(key string, value interface{}) *recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, span := range t.spans {
+		if span.attrs[key] == value {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestClientTracerRequestSpan(t *testing.T) {
+	var (
+		attempts     int
+		traceparents []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"not_found","message":"Conversation Not Exists.","status":404}`))
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewClient(ClientConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL,
+		Retry:   &RetryPolicy{BaseBackoff: time.Millisecond},
+		Tracer:  tracer,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.doRequestWithResponse(context.Background(), http.MethodGet, "/messages?user=u", nil, nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("error = %v, want ErrNotFound", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	want := map[string]interface{}{
+		AttrHTTPMethod:     http.MethodGet,
+		AttrURLPath:        "/messages",
+		AttrRetryCount:     1,
+		AttrHTTPStatusCode: http.StatusNotFound,
+		AttrErrorCode:      ErrCodeNotFound,
+	}
+	for key, value := range want {
+		if span.attrs[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, span.attrs[key], value)
+		}
+	}
+	if span.name != http.MethodGet || span.ended != 1 || len(span.errs) != 1 {
+		t.Errorf("span name = %q, ended = %d, errors = %v", span.name, span.ended, span.errs)
+	}
+
+	for i, tp := range traceparents {
+		if tp != "span-1" {
+			t.Errorf("attempt %d traceparent = %q, want span-1", i+1, tp)
+		}
+	}
+}
+
+func TestStreamReaderNodeSpans(t *testing.T) {
+	events := []string{
+		`{"event":"workflow_started","workflow_run_id":"run","data":{}}`,
+		`{"event":"node_started","workflow_run_id":"run","data":{"id":"e-it","node_id":"it","node_type":"iteration","title":"Iterate"}}`,
+		`{"event":"iteration_started","workflow_run_id":"run","data":{"id":"e-it","node_id":"it","node_type":"iteration","title":"Iterate"}}`,
+		// 并行迭代: 同一 node_id 的两轮同时执行
+		`{"event":"node_started","workflow_run_id":"run","data":{"id":"e-llm-0","node_id":"llm","node_type":"llm","title":"LLM","iteration_id":"it"}}`,
+		`{"event":"node_started","workflow_run_id":"run","data":{"id":"e-llm-1","node_id":"llm","node_type":"llm","title":"LLM","iteration_id":"it"}}`,
+		`{"event":"node_finished","workflow_run_id":"run","data":{"id":"e-llm-1","node_id":"llm","status":"succeeded","elapsed_time":1.5,"iteration_id":"it","execution_metadata":{"total_tokens":10}}}`,
+		`{"event":"node_finished","workflow_run_id":"run","data":{"id":"e-llm-0","node_id":"llm","status":"failed","error":"timeout","elapsed_time":3,"iteration_id":"it","execution_metadata":{"total_tokens":20}}}`,
+		`{"event":"iteration_completed","workflow_run_id":"run","data":{"id":"e-it","node_id":"it","status":"succeeded","total_tokens":30}}`,
+		`{"event":"node_finished","workflow_run_id":"run","data":{"id":"e-it","node_id":"it","node_type":"iteration","status":"succeeded"}}`,
+		`{"event":"workflow_finished","workflow_run_id":"run","data":{"status":"succeeded"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewWorkflowClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL, Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.RunStream(context.Background(), &WorkflowRequest{User: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccumulateWorkflow(stream, nil); err != nil {
+		t.Fatalf("AccumulateWorkflow() error = %v", err)
+	}
+	stream.Close()
+
+	if len(tracer.spans) != 4 {
+		t.Fatalf("spans = %d, want 4 (request, iteration, 2 llm rounds)", len(tracer.spans))
+	}
+	request := tracer.spans[0]
+	iteration := tracer.byAttr(AttrNodeExecutionID, "e-it")
+	round0 := tracer.byAttr(AttrNodeExecutionID, "e-llm-0")
+	round1 := tracer.byAttr(AttrNodeExecutionID, "e-llm-1")
+	if iteration == nil || round0 == nil || round1 == nil {
+		t.Fatalf("missing node spans: iteration=%v round0=%v round1=%v", iteration, round0, round1)
+	}
+
+	if iteration.parent != request || round0.parent != iteration || round1.parent != iteration {
+		t.Error("node spans not nested under request and iteration spans")
+	}
+	for _, span := range tracer.spans {
+		if span.ended != 1 {
+			t.Errorf("span %q ended %d times, want 1", span.name, span.ended)
+		}
+	}
+
+	if round0.attrs[AttrNodeTotalTokens] != 20 || round0.attrs[AttrNodeStatus] != "failed" || len(round0.errs) != 1 {
+		t.Errorf("round 0 attributes = %v, errors = %v", round0.attrs, round0.errs)
+	}
+	if round1.attrs[AttrNodeTotalTokens] != 10 || round1.attrs[AttrNodeElapsedTime] != 1.5 || len(round1.errs) != 0 {
+		t.Errorf("round 1 attributes = %v, errors = %v", round1.attrs, round1.errs)
+	}
+	if iteration.attrs[AttrNodeTotalTokens] != 30 || iteration.attrs[AttrWorkflowRunID] != "run" {
+		t.Errorf("iteration attributes = %v", iteration.attrs)
+	}
+}
+
+// TestStreamChanTracerCancel 在 -race 下校验 ctx 取消时关闭流与读取节点事件不存在数据竞争
+func TestStreamChanTracerCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for i := 0; ; i++ {
+			_, err := fmt.Fprintf(w, "data: {\"event\":\"node_started\",\"data\":{\"id\":\"e-%d\",\"node_id\":\"n%d\",\"title\":\"Node\"}}\n\n", i, i%3)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewWorkflowClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL, Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 20 {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := client.RunStream(ctx, &WorkflowRequest{User: "u"})
+		if err != nil {
+			cancel()
+			t.Fatal(err)
+		}
+
+		events, errc := stream.Chan(ctx)
+		for range 5 {
+			<-events
+		}
+		cancel()
+		for range events {
+		}
+		for err := range errc {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Chan() error = %v, want context.Canceled", err)
+			}
+		}
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	for _, span := range tracer.spans {
+		if span.ended != 1 {
+			t.Fatalf("span %q ended %d times, want 1", span.name, span.ended)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package dify
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TransportConfig 传输层配置
+//
+// 仅在未提供 ClientConfig.HTTPClient 与 ClientConfig.Transport 时生效。
+type TransportConfig struct {
+	RootCAs             *x509.CertPool    // 自定义根证书池, 为 nil 时使用系统证书
+	Certificates        []tls.Certificate // 客户端证书 (mTLS)
+	ProxyURL            string            // 代理地址, 如 http://proxy:8080
+	MaxIdleConns        int               // 最大空闲连接数
+	MaxIdleConnsPerHost int               // 每个主机的最大空闲连接数
+	MaxConnsPerHost     int               // 每个主机的最大连接数
+	IdleConnTimeout     time.Duration     // 空闲连接超时
+	DialTimeout         time.Duration     // 建立连接超时
+	TLSHandshakeTimeout time.Duration     // TLS 握手超时
+	ForceHTTP2          bool              // 自定义 TLS 配置时仍尝试启用 HTTP/2
+}
+
+// newHTTPClient 根据配置创建 HTTP 客户端
+func newHTTPClient(config ClientConfig) (*http.Client, error) {
+	if config.HTTPClient != nil {
+		return config.HTTPClient, nil
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		t, err := newTransport(config.TransportConfig, config.SkipTLS)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
+
+// newTransport 创建 http.Transport
+func newTransport(config TransportConfig, skipTLS bool) (*http.Transport, error) {
+	transport := &http.Transport{
+		MaxIdleConns:        config.MaxIdleConns,
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     config.MaxConnsPerHost,
+		IdleConnTimeout:     config.IdleConnTimeout,
+		TLSHandshakeTimeout: config.TLSHandshakeTimeout,
+		ForceAttemptHTTP2:   config.ForceHTTP2,
+	}
+
+	if config.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: config.DialTimeout}
+		transport.DialContext = dialer.DialContext
+	}
+
+	if proxy := strings.TrimSpace(config.ProxyURL); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if skipTLS || config.RootCAs != nil || len(config.Certificates) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: skipTLS,
+			RootCAs:            config.RootCAs,
+			Certificates:       config.Certificates,
+		}
+	}
+
+	return transport, nil
+}
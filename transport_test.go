@@ -0,0 +1,166 @@
+package dify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClientCertificate 生成自签名的客户端证书
+func newTestClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dify-go-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// get 通过 Client 发送 GET 请求
+func get(t *testing.T, config ClientConfig) error {
+	t.Helper()
+
+	config.APIKey = "test-key"
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client.doRequestWithResponse(context.Background(), http.MethodGet, "/parameters", nil, nil)
+}
+
+func TestTransportTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tests := []struct {
+		name    string
+		config  ClientConfig
+		wantErr bool
+	}{
+		{name: "system roots", config: ClientConfig{BaseURL: server.URL}, wantErr: true},
+		{name: "custom roots", config: ClientConfig{BaseURL: server.URL, TransportConfig: TransportConfig{RootCAs: pool}}},
+		{name: "skip verify", config: ClientConfig{BaseURL: server.URL, SkipTLS: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := get(t, tt.config)
+			var certErr *tls.CertificateVerificationError
+			if tt.wantErr {
+				if !errors.As(err, &certErr) {
+					t.Errorf("error = %v, want certificate verification error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTransportClientCertificates(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "dify-go-test" {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	err := get(t, ClientConfig{
+		BaseURL: server.URL,
+		TransportConfig: TransportConfig{
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{newTestClientCertificate(t)},
+		},
+	})
+	if err != nil {
+		t.Errorf("with client certificate error = %v", err)
+	}
+
+	// 未配置客户端证书时握手失败
+	if err := get(t, ClientConfig{BaseURL: server.URL, TransportConfig: TransportConfig{RootCAs: pool}}); err == nil {
+		t.Error("without client certificate error = nil, want handshake failure")
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 经代理发送的请求使用绝对 URI
+		target = r.URL.String()
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	err := get(t, ClientConfig{
+		BaseURL:         "http://dify.invalid/v1",
+		TransportConfig: TransportConfig{ProxyURL: proxy.URL},
+	})
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if target != "http://dify.invalid/v1/parameters" {
+		t.Errorf("proxied request = %q, want http://dify.invalid/v1/parameters", target)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := newTransport(TransportConfig{
+		MaxIdleConnsPerHost: 7,
+		DialTimeout:         time.Second,
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxIdleConnsPerHost != 7 || transport.DialContext == nil {
+		t.Errorf("transport = %+v, want pool settings and dialer applied", transport)
+	}
+	if transport.TLSClientConfig != nil {
+		t.Error("TLSClientConfig set without TLS options")
+	}
+
+	if _, err := newTransport(TransportConfig{ProxyURL: "http://[::1"}, false); err == nil {
+		t.Error("newTransport() with invalid proxy error = nil")
+	}
+}
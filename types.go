@@ -1,5 +1,10 @@
 package dify
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Usage 表示 token 使用量
 type Usage struct {
 	PromptTokens        int     `json:"prompt_tokens"`
@@ -82,6 +87,45 @@ type ConversationListResponse struct {
 	Limit   int            `json:"limit"`
 }
 
+// 会话变量类型
+const (
+	VariableTypeString       = "string"
+	VariableTypeNumber       = "number"
+	VariableTypeBoolean      = "boolean"
+	VariableTypeObject       = "object"
+	VariableTypeSecret       = "secret"
+	VariableTypeFile         = "file"
+	VariableTypeArrayString  = "array[string]"
+	VariableTypeArrayNumber  = "array[number]"
+	VariableTypeArrayObject  = "array[object]"
+	VariableTypeArrayBoolean = "array[boolean]"
+	VariableTypeArrayFile    = "array[file]"
+)
+
+// ConversationVariable 会话变量
+type ConversationVariable struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	ValueType   string          `json:"value_type"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description"`
+	CreatedAt   int64           `json:"created_at"`
+	UpdatedAt   int64           `json:"updated_at"`
+}
+
+// ConversationVariableListResponse 会话变量列表响应
+type ConversationVariableListResponse struct {
+	Data    []ConversationVariable `json:"data"`
+	HasMore bool                   `json:"has_more"`
+	Limit   int                    `json:"limit"`
+}
+
+// UpdateConversationVariableRequest 更新会话变量请求
+type UpdateConversationVariableRequest struct {
+	Value interface{} `json:"value"`
+	User  string      `json:"user"`
+}
+
 // Message 消息信息
 type Message struct {
 	ID                 string                 `json:"id"`
@@ -92,9 +136,26 @@ type Message struct {
 	MessageFiles       []MessageFile          `json:"message_files"`
 	Feedback           *Feedback              `json:"feedback"`
 	RetrieverResources []RetrieverResource    `json:"retriever_resources"`
+	AgentThoughts      []AgentThought         `json:"agent_thoughts,omitempty"`
 	CreatedAt          int64                  `json:"created_at"`
 }
 
+// AgentThought Agent 思考步骤 (工具调用)
+type AgentThought struct {
+	ID           string                 `json:"id"`
+	ChainID      string                 `json:"chain_id,omitempty"`
+	MessageID    string                 `json:"message_id"`
+	Position     int                    `json:"position"`
+	Thought      string                 `json:"thought"`
+	Tool         string                 `json:"tool"`
+	ToolLabels   map[string]interface{} `json:"tool_labels,omitempty"`
+	ToolInput    string                 `json:"tool_input"`
+	Observation  string                 `json:"observation"`
+	MessageFiles []string               `json:"message_files,omitempty"`
+	Files        []string               `json:"files,omitempty"`
+	CreatedAt    int64                  `json:"created_at"`
+}
+
 // MessageFile 消息文件
 type MessageFile struct {
 	ID        string `json:"id"`
@@ -176,6 +237,9 @@ type WorkflowRequest struct {
 	ResponseMode string                 `json:"response_mode"`
 	User         string                 `json:"user"`
 	Files        []FileInput            `json:"files,omitempty"`
+
+	// WorkflowID 指定要执行的已发布工作流版本, 为空时执行最新发布版本
+	WorkflowID string `json:"-"`
 }
 
 // WorkflowResponse 工作流执行响应 (blocking 模式)
@@ -188,7 +252,7 @@ type WorkflowResponse struct {
 // WorkflowData 工作流数据
 type WorkflowData struct {
 	ID          string                 `json:"id"`
-	WorkflowID  string                 `json:"workflow_id"`
+	WorkflowID  string                 `json:"workflow_id"` // 实际执行的工作流版本 ID
 	Status      string                 `json:"status"`
 	Outputs     map[string]interface{} `json:"outputs"`
 	Error       string                 `json:"error,omitempty"`
@@ -214,6 +278,76 @@ type WorkflowRunResponse struct {
 	ElapsedTime float64                `json:"elapsed_time"`
 }
 
+// 工作流执行状态
+const (
+	WorkflowStatusRunning   = "running"
+	WorkflowStatusSucceeded = "succeeded"
+	WorkflowStatusFailed    = "failed"
+	WorkflowStatusStopped   = "stopped"
+)
+
+// WorkflowLogListOptions 工作流日志查询参数
+type WorkflowLogListOptions struct {
+	Keyword                   string
+	Status                    string // succeeded / failed / stopped
+	CreatedAtBefore           time.Time
+	CreatedAtAfter            time.Time
+	CreatedByEndUserSessionID string
+	CreatedByAccount          string
+	Page                      int
+	Limit                     int
+}
+
+// WorkflowLogListResponse 工作流日志列表响应
+type WorkflowLogListResponse struct {
+	Data    []WorkflowLog `json:"data"`
+	HasMore bool          `json:"has_more"`
+	Limit   int           `json:"limit"`
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+}
+
+// WorkflowLog 工作流日志
+type WorkflowLog struct {
+	ID               string         `json:"id"`
+	WorkflowRun      WorkflowLogRun `json:"workflow_run"`
+	CreatedFrom      string         `json:"created_from"`
+	CreatedByRole    string         `json:"created_by_role"`
+	CreatedByAccount *LogAccount    `json:"created_by_account"`
+	CreatedByEndUser *LogEndUser    `json:"created_by_end_user"`
+	CreatedAt        int64          `json:"created_at"`
+}
+
+// WorkflowLogRun 日志中的工作流执行记录
+type WorkflowLogRun struct {
+	ID              string  `json:"id"`
+	Version         string  `json:"version"`
+	Status          string  `json:"status"`
+	Error           string  `json:"error,omitempty"`
+	ElapsedTime     float64 `json:"elapsed_time"`
+	TotalTokens     int     `json:"total_tokens"`
+	TotalSteps      int     `json:"total_steps"`
+	CreatedAt       int64   `json:"created_at"`
+	FinishedAt      int64   `json:"finished_at"`
+	ExceptionsCount int     `json:"exceptions_count"`
+	TriggeredFrom   string  `json:"triggered_from"`
+}
+
+// LogAccount 日志中的控制台账号
+type LogAccount struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// LogEndUser 日志中的终端用户
+type LogEndUser struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	IsAnonymous bool   `json:"is_anonymous"`
+	SessionID   string `json:"session_id"`
+}
+
 // ========== 流式事件类型 ==========
 
 // StreamEvent 流式事件基础结构
@@ -231,6 +365,34 @@ type MessageStreamEvent struct {
 	CreatedAt      int64  `json:"created_at"`
 }
 
+// AgentMessageEvent Agent 模式下的消息流事件
+type AgentMessageEvent struct {
+	Event          string `json:"event"`
+	TaskID         string `json:"task_id"`
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Answer         string `json:"answer"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// AgentThoughtEvent Agent 思考步骤事件, 同一步骤会以相同 id 多次推送
+type AgentThoughtEvent struct {
+	Event          string `json:"event"`
+	TaskID         string `json:"task_id"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	AgentThought
+}
+
+// MessageReplaceEvent 消息内容替换事件 (内容审查触发时替换全部已输出内容)
+type MessageReplaceEvent struct {
+	Event          string `json:"event"`
+	TaskID         string `json:"task_id"`
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	Answer         string `json:"answer"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
 // MessageEndStreamEvent 消息结束流事件
 type MessageEndStreamEvent struct {
 	Event          string   `json:"event"`
@@ -240,28 +402,63 @@ type MessageEndStreamEvent struct {
 	Metadata       Metadata `json:"metadata"`
 }
 
+// MessageFileEvent 文件消息事件 (如工具生成的图片)
+type MessageFileEvent struct {
+	Event          string `json:"event"`
+	ID             string `json:"id"`
+	TaskID         string `json:"task_id,omitempty"`
+	MessageID      string `json:"message_id,omitempty"`
+	Type           string `json:"type"`
+	BelongsTo      string `json:"belongs_to"`
+	URL            string `json:"url"`
+	ConversationID string `json:"conversation_id"`
+}
+
+// TTSMessageEvent 语音合成音频事件, Audio 为 base64 编码的音频块
+type TTSMessageEvent struct {
+	Event     string `json:"event"`
+	TaskID    string `json:"task_id"`
+	MessageID string `json:"message_id"`
+	Audio     string `json:"audio"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// TTSMessageEndEvent 语音合成结束事件
+type TTSMessageEndEvent struct {
+	Event     string `json:"event"`
+	TaskID    string `json:"task_id"`
+	MessageID string `json:"message_id"`
+	Audio     string `json:"audio"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 // WorkflowStartedEvent 工作流开始事件
 type WorkflowStartedEvent struct {
-	Event         string              `json:"event"`
-	TaskID        string              `json:"task_id"`
-	WorkflowRunID string              `json:"workflow_run_id"`
-	Data          WorkflowStartedData `json:"data"`
+	Event          string              `json:"event"`
+	TaskID         string              `json:"task_id"`
+	WorkflowRunID  string              `json:"workflow_run_id"`
+	ConversationID string              `json:"conversation_id,omitempty"`
+	MessageID      string              `json:"message_id,omitempty"`
+	Data           WorkflowStartedData `json:"data"`
 }
 
 // WorkflowStartedData 工作流开始数据
 type WorkflowStartedData struct {
-	ID          string `json:"id"`
-	WorkflowID  string `json:"workflow_id"`
-	SequenceNum int    `json:"sequence_number"`
-	CreatedAt   int64  `json:"created_at"`
+	ID          string                 `json:"id"`
+	WorkflowID  string                 `json:"workflow_id"`
+	SequenceNum int                    `json:"sequence_number"`
+	Inputs      map[string]interface{} `json:"inputs,omitempty"`
+	CreatedAt   int64                  `json:"created_at"`
 }
 
 // NodeStartedEvent 节点开始事件
 type NodeStartedEvent struct {
-	Event         string          `json:"event"`
-	TaskID        string          `json:"task_id"`
-	WorkflowRunID string          `json:"workflow_run_id"`
-	Data          NodeStartedData `json:"data"`
+	Event          string          `json:"event"`
+	TaskID         string          `json:"task_id"`
+	WorkflowRunID  string          `json:"workflow_run_id"`
+	ConversationID string          `json:"conversation_id,omitempty"`
+	MessageID      string          `json:"message_id,omitempty"`
+	Data           NodeStartedData `json:"data"`
 }
 
 // NodeStartedData 节点开始数据
@@ -274,14 +471,28 @@ type NodeStartedData struct {
 	PredecessorNodeID string                 `json:"predecessor_node_id"`
 	Inputs            map[string]interface{} `json:"inputs"`
 	CreatedAt         int64                  `json:"created_at"`
+	NodeScope
+}
+
+// NodeScope 节点所属的并行分支、迭代与循环, 顶层节点各字段为空
+type NodeScope struct {
+	ParallelID                string `json:"parallel_id,omitempty"`
+	ParallelStartNodeID       string `json:"parallel_start_node_id,omitempty"`
+	ParentParallelID          string `json:"parent_parallel_id,omitempty"`
+	ParentParallelStartNodeID string `json:"parent_parallel_start_node_id,omitempty"`
+	ParallelRunID             string `json:"parallel_run_id,omitempty"`
+	IterationID               string `json:"iteration_id,omitempty"`
+	LoopID                    string `json:"loop_id,omitempty"`
 }
 
 // NodeFinishedEvent 节点完成事件
 type NodeFinishedEvent struct {
-	Event         string           `json:"event"`
-	TaskID        string           `json:"task_id"`
-	WorkflowRunID string           `json:"workflow_run_id"`
-	Data          NodeFinishedData `json:"data"`
+	Event          string           `json:"event"`
+	TaskID         string           `json:"task_id"`
+	WorkflowRunID  string           `json:"workflow_run_id"`
+	ConversationID string           `json:"conversation_id,omitempty"`
+	MessageID      string           `json:"message_id,omitempty"`
+	Data           NodeFinishedData `json:"data"`
 }
 
 // NodeFinishedData 节点完成数据
@@ -300,14 +511,204 @@ type NodeFinishedData struct {
 	ElapsedTime       float64                `json:"elapsed_time"`
 	ExecutionMetadata map[string]interface{} `json:"execution_metadata"`
 	CreatedAt         int64                  `json:"created_at"`
+	FinishedAt        int64                  `json:"finished_at,omitempty"`
+	NodeScope
+}
+
+// NodeRetryEvent 节点重试事件
+type NodeRetryEvent struct {
+	Event          string        `json:"event"`
+	TaskID         string        `json:"task_id"`
+	WorkflowRunID  string        `json:"workflow_run_id"`
+	ConversationID string        `json:"conversation_id,omitempty"`
+	MessageID      string        `json:"message_id,omitempty"`
+	Data           NodeRetryData `json:"data"`
+}
+
+// NodeRetryData 节点重试数据, 内容为失败的那次执行
+type NodeRetryData struct {
+	NodeFinishedData
+	RetryIndex int `json:"retry_index"`
+}
+
+// IterationStartedEvent 迭代开始事件
+type IterationStartedEvent struct {
+	Event          string               `json:"event"`
+	TaskID         string               `json:"task_id"`
+	WorkflowRunID  string               `json:"workflow_run_id"`
+	ConversationID string               `json:"conversation_id,omitempty"`
+	MessageID      string               `json:"message_id,omitempty"`
+	Data           IterationStartedData `json:"data"`
+}
+
+// IterationStartedData 迭代/循环开始数据
+type IterationStartedData struct {
+	ID                  string                 `json:"id"`
+	NodeID              string                 `json:"node_id"`
+	NodeType            string                 `json:"node_type"`
+	Title               string                 `json:"title"`
+	Inputs              map[string]interface{} `json:"inputs"`
+	Metadata            map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt           int64                  `json:"created_at"`
+	ParallelID          string                 `json:"parallel_id,omitempty"`
+	ParallelStartNodeID string                 `json:"parallel_start_node_id,omitempty"`
+}
+
+// IterationNextEvent 进入下一轮迭代事件
+type IterationNextEvent struct {
+	Event          string            `json:"event"`
+	TaskID         string            `json:"task_id"`
+	WorkflowRunID  string            `json:"workflow_run_id"`
+	ConversationID string            `json:"conversation_id,omitempty"`
+	MessageID      string            `json:"message_id,omitempty"`
+	Data           IterationNextData `json:"data"`
+}
+
+// IterationNextData 下一轮迭代数据
+type IterationNextData struct {
+	ID                  string      `json:"id"`
+	NodeID              string      `json:"node_id"`
+	NodeType            string      `json:"node_type"`
+	Title               string      `json:"title"`
+	Index               int         `json:"index"`
+	PreIterationOutput  interface{} `json:"pre_iteration_output,omitempty"`
+	CreatedAt           int64       `json:"created_at"`
+	ParallelID          string      `json:"parallel_id,omitempty"`
+	ParallelStartNodeID string      `json:"parallel_start_node_id,omitempty"`
+	ParallelModeRunID   string      `json:"parallel_mode_run_id,omitempty"`
+	Duration            float64     `json:"duration,omitempty"`
+}
+
+// IterationCompletedEvent 迭代完成事件
+type IterationCompletedEvent struct {
+	Event          string                 `json:"event"`
+	TaskID         string                 `json:"task_id"`
+	WorkflowRunID  string                 `json:"workflow_run_id"`
+	ConversationID string                 `json:"conversation_id,omitempty"`
+	MessageID      string                 `json:"message_id,omitempty"`
+	Data           IterationCompletedData `json:"data"`
+}
+
+// IterationCompletedData 迭代/循环完成数据
+type IterationCompletedData struct {
+	ID                  string                 `json:"id"`
+	NodeID              string                 `json:"node_id"`
+	NodeType            string                 `json:"node_type"`
+	Title               string                 `json:"title"`
+	Inputs              map[string]interface{} `json:"inputs"`
+	Outputs             map[string]interface{} `json:"outputs"`
+	Status              string                 `json:"status"`
+	Error               string                 `json:"error,omitempty"`
+	ElapsedTime         float64                `json:"elapsed_time"`
+	TotalTokens         int                    `json:"total_tokens"`
+	ExecutionMetadata   map[string]interface{} `json:"execution_metadata"`
+	Steps               int                    `json:"steps"`
+	CreatedAt           int64                  `json:"created_at"`
+	FinishedAt          int64                  `json:"finished_at"`
+	ParallelID          string                 `json:"parallel_id,omitempty"`
+	ParallelStartNodeID string                 `json:"parallel_start_node_id,omitempty"`
+}
+
+// LoopStartedEvent 循环开始事件
+type LoopStartedEvent struct {
+	Event          string               `json:"event"`
+	TaskID         string               `json:"task_id"`
+	WorkflowRunID  string               `json:"workflow_run_id"`
+	ConversationID string               `json:"conversation_id,omitempty"`
+	MessageID      string               `json:"message_id,omitempty"`
+	Data           IterationStartedData `json:"data"`
+}
+
+// LoopNextEvent 进入下一轮循环事件
+type LoopNextEvent struct {
+	Event          string       `json:"event"`
+	TaskID         string       `json:"task_id"`
+	WorkflowRunID  string       `json:"workflow_run_id"`
+	ConversationID string       `json:"conversation_id,omitempty"`
+	MessageID      string       `json:"message_id,omitempty"`
+	Data           LoopNextData `json:"data"`
+}
+
+// LoopNextData 下一轮循环数据
+type LoopNextData struct {
+	ID                  string      `json:"id"`
+	NodeID              string      `json:"node_id"`
+	NodeType            string      `json:"node_type"`
+	Title               string      `json:"title"`
+	Index               int         `json:"index"`
+	PreLoopOutput       interface{} `json:"pre_loop_output,omitempty"`
+	CreatedAt           int64       `json:"created_at"`
+	ParallelID          string      `json:"parallel_id,omitempty"`
+	ParallelStartNodeID string      `json:"parallel_start_node_id,omitempty"`
+	ParallelModeRunID   string      `json:"parallel_mode_run_id,omitempty"`
+	Duration            float64     `json:"duration,omitempty"`
+}
+
+// LoopCompletedEvent 循环完成事件
+type LoopCompletedEvent struct {
+	Event          string                 `json:"event"`
+	TaskID         string                 `json:"task_id"`
+	WorkflowRunID  string                 `json:"workflow_run_id"`
+	ConversationID string                 `json:"conversation_id,omitempty"`
+	MessageID      string                 `json:"message_id,omitempty"`
+	Data           IterationCompletedData `json:"data"`
+}
+
+// ParallelBranchStartedEvent 并行分支开始事件
+type ParallelBranchStartedEvent struct {
+	Event          string             `json:"event"`
+	TaskID         string             `json:"task_id"`
+	WorkflowRunID  string             `json:"workflow_run_id"`
+	ConversationID string             `json:"conversation_id,omitempty"`
+	MessageID      string             `json:"message_id,omitempty"`
+	Data           ParallelBranchData `json:"data"`
+}
+
+// ParallelBranchFinishedEvent 并行分支结束事件
+type ParallelBranchFinishedEvent struct {
+	Event          string             `json:"event"`
+	TaskID         string             `json:"task_id"`
+	WorkflowRunID  string             `json:"workflow_run_id"`
+	ConversationID string             `json:"conversation_id,omitempty"`
+	MessageID      string             `json:"message_id,omitempty"`
+	Data           ParallelBranchData `json:"data"`
+}
+
+// ParallelBranchData 并行分支数据
+type ParallelBranchData struct {
+	ParallelID                string `json:"parallel_id"`
+	ParallelStartNodeID       string `json:"parallel_start_node_id"`
+	ParentParallelID          string `json:"parent_parallel_id,omitempty"`
+	ParentParallelStartNodeID string `json:"parent_parallel_start_node_id,omitempty"`
+	IterationID               string `json:"iteration_id,omitempty"`
+	LoopID                    string `json:"loop_id,omitempty"`
+	Status                    string `json:"status,omitempty"`
+	Error                     string `json:"error,omitempty"`
+	CreatedAt                 int64  `json:"created_at"`
 }
 
 // WorkflowFinishedEvent 工作流完成事件
 type WorkflowFinishedEvent struct {
-	Event         string       `json:"event"`
-	TaskID        string       `json:"task_id"`
-	WorkflowRunID string       `json:"workflow_run_id"`
-	Data          WorkflowData `json:"data"`
+	Event          string       `json:"event"`
+	TaskID         string       `json:"task_id"`
+	WorkflowRunID  string       `json:"workflow_run_id"`
+	ConversationID string       `json:"conversation_id,omitempty"`
+	MessageID      string       `json:"message_id,omitempty"`
+	Data           WorkflowData `json:"data"`
+}
+
+// TextChunkEvent 文本块事件
+type TextChunkEvent struct {
+	Event         string        `json:"event"`
+	TaskID        string        `json:"task_id"`
+	WorkflowRunID string        `json:"workflow_run_id"`
+	Data          TextChunkData `json:"data"`
+}
+
+// TextChunkData 文本块数据
+type TextChunkData struct {
+	Text                 string   `json:"text"`
+	FromVariableSelector []string `json:"from_variable_selector"`
 }
 
 // ErrorStreamEvent 错误流事件
@@ -426,3 +827,609 @@ type StopResponse struct {
 type AudioToTextResponse struct {
 	Text string `json:"text"`
 }
+
+// ========== Annotation 相关类型 ==========
+
+// Annotation 标注
+type Annotation struct {
+	ID        string `json:"id"`
+	Question  string `json:"question"`
+	Answer    string `json:"answer"`
+	HitCount  int    `json:"hit_count"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// AnnotationRequest 创建/更新标注请求
+type AnnotationRequest struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// AnnotationListResponse 标注列表响应
+type AnnotationListResponse struct {
+	Data    []Annotation `json:"data"`
+	HasMore bool         `json:"has_more"`
+	Limit   int          `json:"limit"`
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+}
+
+// 标注回复开关操作
+const (
+	AnnotationReplyEnable  = "enable"
+	AnnotationReplyDisable = "disable"
+)
+
+// 标注回复任务状态
+const (
+	AnnotationJobWaiting    = "waiting"
+	AnnotationJobProcessing = "processing"
+	AnnotationJobCompleted  = "completed"
+	AnnotationJobError      = "error"
+)
+
+// AnnotationReplyRequest 启用标注回复请求
+type AnnotationReplyRequest struct {
+	EmbeddingProviderName string  `json:"embedding_provider_name"`
+	EmbeddingModelName    string  `json:"embedding_model_name"`
+	ScoreThreshold        float64 `json:"score_threshold"`
+}
+
+// AnnotationReplyJob 标注回复开关的异步任务
+type AnnotationReplyJob struct {
+	JobID     string `json:"job_id"`
+	JobStatus string `json:"job_status"`
+	ErrorMsg  string `json:"error_msg,omitempty"`
+}
+
+// ========== Knowledge 相关类型 ==========
+
+// 索引方式
+const (
+	IndexingTechniqueHighQuality = "high_quality"
+	IndexingTechniqueEconomy     = "economy"
+)
+
+// 知识库权限
+const (
+	DatasetPermissionOnlyMe         = "only_me"
+	DatasetPermissionAllTeamMembers = "all_team_members"
+	DatasetPermissionPartialMembers = "partial_members"
+)
+
+// 检索方式
+const (
+	SearchMethodSemantic = "semantic_search"
+	SearchMethodFullText = "full_text_search"
+	SearchMethodHybrid   = "hybrid_search"
+	SearchMethodKeyword  = "keyword_search"
+)
+
+// RetrievalModel 检索模型配置
+type RetrievalModel struct {
+	SearchMethod          string            `json:"search_method"`
+	RerankingEnable       bool              `json:"reranking_enable"`
+	RerankingMode         string            `json:"reranking_mode,omitempty"`
+	RerankingModel        *RerankingModel   `json:"reranking_model,omitempty"`
+	Weights               *RetrievalWeights `json:"weights,omitempty"`
+	TopK                  int               `json:"top_k"`
+	ScoreThresholdEnabled bool              `json:"score_threshold_enabled"`
+	ScoreThreshold        float64           `json:"score_threshold"`
+
+	MetadataFilteringConditions *MetadataFilteringConditions `json:"metadata_filtering_conditions,omitempty"`
+}
+
+// MetadataFilteringConditions 元数据过滤条件
+type MetadataFilteringConditions struct {
+	LogicalOperator string              `json:"logical_operator"`
+	Conditions      []MetadataCondition `json:"conditions"`
+}
+
+// MetadataCondition 单个元数据过滤条件
+type MetadataCondition struct {
+	Name               string      `json:"name"`
+	ComparisonOperator string      `json:"comparison_operator"`
+	Value              interface{} `json:"value,omitempty"`
+}
+
+// RerankingModel Rerank 模型
+type RerankingModel struct {
+	RerankingProviderName string `json:"reranking_provider_name"`
+	RerankingModelName    string `json:"reranking_model_name"`
+}
+
+// RetrievalWeights 混合检索权重
+type RetrievalWeights struct {
+	WeightType     string               `json:"weight_type,omitempty"`
+	VectorSetting  *VectorWeightSetting `json:"vector_setting,omitempty"`
+	KeywordSetting *KeywordSetting      `json:"keyword_setting,omitempty"`
+}
+
+// VectorWeightSetting 向量检索权重
+type VectorWeightSetting struct {
+	VectorWeight          float64 `json:"vector_weight"`
+	EmbeddingProviderName string  `json:"embedding_provider_name"`
+	EmbeddingModelName    string  `json:"embedding_model_name"`
+}
+
+// KeywordSetting 关键词检索权重
+type KeywordSetting struct {
+	KeywordWeight float64 `json:"keyword_weight"`
+}
+
+// Dataset 知识库
+type Dataset struct {
+	ID                     string          `json:"id"`
+	Name                   string          `json:"name"`
+	Description            string          `json:"description"`
+	Provider               string          `json:"provider"`
+	Permission             string          `json:"permission"`
+	DataSourceType         string          `json:"data_source_type"`
+	IndexingTechnique      string          `json:"indexing_technique"`
+	AppCount               int             `json:"app_count"`
+	DocumentCount          int             `json:"document_count"`
+	WordCount              int             `json:"word_count"`
+	CreatedBy              string          `json:"created_by"`
+	CreatedAt              int64           `json:"created_at"`
+	UpdatedBy              string          `json:"updated_by"`
+	UpdatedAt              int64           `json:"updated_at"`
+	EmbeddingModel         string          `json:"embedding_model"`
+	EmbeddingModelProvider string          `json:"embedding_model_provider"`
+	EmbeddingAvailable     bool            `json:"embedding_available"`
+	RetrievalModel         *RetrievalModel `json:"retrieval_model_dict,omitempty"`
+	Tags                   []DatasetTag    `json:"tags"`
+	DocForm                string          `json:"doc_form"`
+}
+
+// DatasetTag 知识库标签
+type DatasetTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// CreateDatasetRequest 创建知识库请求
+type CreateDatasetRequest struct {
+	Name                   string          `json:"name"`
+	Description            string          `json:"description,omitempty"`
+	IndexingTechnique      string          `json:"indexing_technique,omitempty"`
+	Permission             string          `json:"permission,omitempty"`
+	Provider               string          `json:"provider,omitempty"`
+	ExternalKnowledgeAPIID string          `json:"external_knowledge_api_id,omitempty"`
+	ExternalKnowledgeID    string          `json:"external_knowledge_id,omitempty"`
+	EmbeddingModel         string          `json:"embedding_model,omitempty"`
+	EmbeddingModelProvider string          `json:"embedding_model_provider,omitempty"`
+	RetrievalModel         *RetrievalModel `json:"retrieval_model,omitempty"`
+}
+
+// UpdateDatasetRequest 更新知识库请求, 仅发送非空字段
+type UpdateDatasetRequest struct {
+	Name                   string          `json:"name,omitempty"`
+	Description            *string         `json:"description,omitempty"`
+	IndexingTechnique      string          `json:"indexing_technique,omitempty"`
+	Permission             string          `json:"permission,omitempty"`
+	EmbeddingModel         string          `json:"embedding_model,omitempty"`
+	EmbeddingModelProvider string          `json:"embedding_model_provider,omitempty"`
+	RetrievalModel         *RetrievalModel `json:"retrieval_model,omitempty"`
+	PartialMemberList      []string        `json:"partial_member_list,omitempty"`
+}
+
+// DatasetListOptions 知识库列表查询参数
+type DatasetListOptions struct {
+	Keyword    string
+	TagIDs     []string
+	IncludeAll bool
+	Page       int
+	Limit      int
+}
+
+// DatasetListResponse 知识库列表响应
+type DatasetListResponse struct {
+	Data    []Dataset `json:"data"`
+	HasMore bool      `json:"has_more"`
+	Limit   int       `json:"limit"`
+	Total   int       `json:"total"`
+	Page    int       `json:"page"`
+}
+
+// 文档分段模式
+const (
+	DocFormText         = "text_model"
+	DocFormHierarchical = "hierarchical_model"
+	DocFormQA           = "qa_model"
+)
+
+// 处理规则模式
+const (
+	ProcessModeAutomatic    = "automatic"
+	ProcessModeCustom       = "custom"
+	ProcessModeHierarchical = "hierarchical"
+)
+
+// 预处理规则
+const (
+	PreProcessingRemoveExtraSpaces = "remove_extra_spaces"
+	PreProcessingRemoveURLsEmails  = "remove_urls_emails"
+)
+
+// 父子分段中父块的召回模式
+const (
+	ParentModeFullDoc   = "full-doc"
+	ParentModeParagraph = "paragraph"
+)
+
+// ProcessRule 文档处理规则
+type ProcessRule struct {
+	Mode  string        `json:"mode"`
+	Rules *ProcessRules `json:"rules,omitempty"`
+}
+
+// ProcessRules 自定义处理规则
+type ProcessRules struct {
+	PreProcessingRules   []PreProcessingRule `json:"pre_processing_rules,omitempty"`
+	Segmentation         *Segmentation       `json:"segmentation,omitempty"`
+	ParentMode           string              `json:"parent_mode,omitempty"`
+	SubchunkSegmentation *Segmentation       `json:"subchunk_segmentation,omitempty"`
+}
+
+// PreProcessingRule 预处理规则
+type PreProcessingRule struct {
+	ID      string `json:"id"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Segmentation 分段规则
+type Segmentation struct {
+	Separator    string `json:"separator,omitempty"`
+	MaxTokens    int    `json:"max_tokens"`
+	ChunkOverlap int    `json:"chunk_overlap,omitempty"`
+}
+
+// Document 知识库文档
+type Document struct {
+	ID                   string                 `json:"id"`
+	Position             int                    `json:"position"`
+	DataSourceType       string                 `json:"data_source_type"`
+	DataSourceInfo       map[string]interface{} `json:"data_source_info"`
+	DatasetProcessRuleID string                 `json:"dataset_process_rule_id"`
+	Name                 string                 `json:"name"`
+	CreatedFrom          string                 `json:"created_from"`
+	CreatedBy            string                 `json:"created_by"`
+	CreatedAt            int64                  `json:"created_at"`
+	Tokens               int                    `json:"tokens"`
+	IndexingStatus       string                 `json:"indexing_status"`
+	Error                string                 `json:"error,omitempty"`
+	Enabled              bool                   `json:"enabled"`
+	DisabledAt           int64                  `json:"disabled_at,omitempty"`
+	DisabledBy           string                 `json:"disabled_by,omitempty"`
+	Archived             bool                   `json:"archived"`
+	DisplayStatus        string                 `json:"display_status"`
+	WordCount            int                    `json:"word_count"`
+	HitCount             int                    `json:"hit_count"`
+	DocForm              string                 `json:"doc_form"`
+}
+
+// DocumentResponse 创建/更新文档响应
+type DocumentResponse struct {
+	Document Document `json:"document"`
+	Batch    string   `json:"batch"`
+}
+
+// CreateDocumentByTextRequest 通过文本创建文档请求
+type CreateDocumentByTextRequest struct {
+	Name                   string          `json:"name"`
+	Text                   string          `json:"text"`
+	IndexingTechnique      string          `json:"indexing_technique,omitempty"`
+	DocForm                string          `json:"doc_form,omitempty"`
+	DocLanguage            string          `json:"doc_language,omitempty"`
+	ProcessRule            *ProcessRule    `json:"process_rule,omitempty"`
+	RetrievalModel         *RetrievalModel `json:"retrieval_model,omitempty"`
+	EmbeddingModel         string          `json:"embedding_model,omitempty"`
+	EmbeddingModelProvider string          `json:"embedding_model_provider,omitempty"`
+}
+
+// CreateDocumentByFileRequest 通过文件创建文档请求 (作为 multipart 的 data 字段发送)
+type CreateDocumentByFileRequest struct {
+	OriginalDocumentID     string          `json:"original_document_id,omitempty"`
+	IndexingTechnique      string          `json:"indexing_technique,omitempty"`
+	DocForm                string          `json:"doc_form,omitempty"`
+	DocLanguage            string          `json:"doc_language,omitempty"`
+	ProcessRule            *ProcessRule    `json:"process_rule,omitempty"`
+	RetrievalModel         *RetrievalModel `json:"retrieval_model,omitempty"`
+	EmbeddingModel         string          `json:"embedding_model,omitempty"`
+	EmbeddingModelProvider string          `json:"embedding_model_provider,omitempty"`
+}
+
+// UpdateDocumentByTextRequest 通过文本更新文档请求
+type UpdateDocumentByTextRequest struct {
+	Name        string       `json:"name,omitempty"`
+	Text        string       `json:"text,omitempty"`
+	DocForm     string       `json:"doc_form,omitempty"`
+	ProcessRule *ProcessRule `json:"process_rule,omitempty"`
+}
+
+// UpdateDocumentByFileRequest 通过文件更新文档请求 (作为 multipart 的 data 字段发送)
+type UpdateDocumentByFileRequest struct {
+	Name        string       `json:"name,omitempty"`
+	DocForm     string       `json:"doc_form,omitempty"`
+	ProcessRule *ProcessRule `json:"process_rule,omitempty"`
+}
+
+// DocumentListOptions 文档列表查询参数
+type DocumentListOptions struct {
+	Keyword string
+	Status  string
+	Page    int
+	Limit   int
+}
+
+// DocumentListResponse 文档列表响应
+type DocumentListResponse struct {
+	Data    []Document `json:"data"`
+	HasMore bool       `json:"has_more"`
+	Limit   int        `json:"limit"`
+	Total   int        `json:"total"`
+	Page    int        `json:"page"`
+}
+
+// 文档索引状态
+const (
+	IndexingStatusWaiting   = "waiting"
+	IndexingStatusParsing   = "parsing"
+	IndexingStatusCleaning  = "cleaning"
+	IndexingStatusSplitting = "splitting"
+	IndexingStatusIndexing  = "indexing"
+	IndexingStatusCompleted = "completed"
+	IndexingStatusPaused    = "paused"
+	IndexingStatusError     = "error"
+	IndexingStatusStopped   = "stopped"
+)
+
+// IndexingStatus 文档索引进度
+type IndexingStatus struct {
+	ID                   string `json:"id"`
+	IndexingStatus       string `json:"indexing_status"`
+	ProcessingStartedAt  int64  `json:"processing_started_at"`
+	ParsingCompletedAt   int64  `json:"parsing_completed_at"`
+	CleaningCompletedAt  int64  `json:"cleaning_completed_at"`
+	SplittingCompletedAt int64  `json:"splitting_completed_at"`
+	CompletedAt          int64  `json:"completed_at"`
+	PausedAt             int64  `json:"paused_at"`
+	StoppedAt            int64  `json:"stopped_at"`
+	Error                string `json:"error"`
+	CompletedSegments    int    `json:"completed_segments"`
+	TotalSegments        int    `json:"total_segments"`
+}
+
+// IndexingStatusResponse 索引进度响应
+type IndexingStatusResponse struct {
+	Data []IndexingStatus `json:"data"`
+}
+
+// Segment 文档分段
+type Segment struct {
+	ID            string       `json:"id"`
+	Position      int          `json:"position"`
+	DocumentID    string       `json:"document_id"`
+	Content       string       `json:"content"`
+	SignContent   string       `json:"sign_content"`
+	Answer        string       `json:"answer"`
+	WordCount     int          `json:"word_count"`
+	Tokens        int          `json:"tokens"`
+	Keywords      []string     `json:"keywords"`
+	IndexNodeID   string       `json:"index_node_id"`
+	IndexNodeHash string       `json:"index_node_hash"`
+	HitCount      int          `json:"hit_count"`
+	Enabled       bool         `json:"enabled"`
+	DisabledAt    int64        `json:"disabled_at,omitempty"`
+	DisabledBy    string       `json:"disabled_by,omitempty"`
+	Status        string       `json:"status"`
+	CreatedBy     string       `json:"created_by"`
+	CreatedAt     int64        `json:"created_at"`
+	UpdatedAt     int64        `json:"updated_at"`
+	IndexingAt    int64        `json:"indexing_at"`
+	CompletedAt   int64        `json:"completed_at"`
+	Error         string       `json:"error,omitempty"`
+	StoppedAt     int64        `json:"stopped_at,omitempty"`
+	ChildChunks   []ChildChunk `json:"child_chunks,omitempty"`
+}
+
+// ChildChunk 父子分段模式下的子分段
+type ChildChunk struct {
+	ID          string `json:"id"`
+	SegmentID   string `json:"segment_id"`
+	Content     string `json:"content"`
+	Position    int    `json:"position"`
+	WordCount   int    `json:"word_count"`
+	Type        string `json:"type"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+	IndexingAt  int64  `json:"indexing_at,omitempty"`
+	CompletedAt int64  `json:"completed_at,omitempty"`
+	Error       string `json:"error,omitempty"`
+	StoppedAt   int64  `json:"stopped_at,omitempty"`
+}
+
+// SegmentInput 新增分段内容
+type SegmentInput struct {
+	Content  string   `json:"content"`
+	Answer   string   `json:"answer,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// CreateSegmentsRequest 新增分段请求
+type CreateSegmentsRequest struct {
+	Segments []SegmentInput `json:"segments"`
+}
+
+// UpdateSegmentRequest 更新分段请求
+type UpdateSegmentRequest struct {
+	Segment SegmentUpdate `json:"segment"`
+}
+
+// SegmentUpdate 分段更新内容
+type SegmentUpdate struct {
+	Content               string   `json:"content"`
+	Answer                string   `json:"answer,omitempty"`
+	Keywords              []string `json:"keywords,omitempty"`
+	Enabled               *bool    `json:"enabled,omitempty"`
+	RegenerateChildChunks bool     `json:"regenerate_child_chunks,omitempty"`
+}
+
+// SegmentListOptions 分段列表查询参数
+type SegmentListOptions struct {
+	Keyword string
+	Status  string
+	Page    int
+	Limit   int
+}
+
+// SegmentListResponse 分段列表响应
+type SegmentListResponse struct {
+	Data    []Segment `json:"data"`
+	DocForm string    `json:"doc_form"`
+	HasMore bool      `json:"has_more"`
+	Limit   int       `json:"limit"`
+	Total   int       `json:"total"`
+	Page    int       `json:"page"`
+}
+
+// SegmentsResponse 新增分段响应
+type SegmentsResponse struct {
+	Data    []Segment `json:"data"`
+	DocForm string    `json:"doc_form"`
+}
+
+// SegmentResponse 单个分段响应
+type SegmentResponse struct {
+	Data    Segment `json:"data"`
+	DocForm string  `json:"doc_form"`
+}
+
+// ChildChunkListOptions 子分段列表查询参数
+type ChildChunkListOptions struct {
+	Keyword string
+	Page    int
+	Limit   int
+}
+
+// ChildChunkListResponse 子分段列表响应
+type ChildChunkListResponse struct {
+	Data       []ChildChunk `json:"data"`
+	Total      int          `json:"total"`
+	TotalPages int          `json:"total_pages"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+}
+
+// ChildChunkResponse 单个子分段响应
+type ChildChunkResponse struct {
+	Data ChildChunk `json:"data"`
+}
+
+// RetrieveRequest 知识库检索请求
+type RetrieveRequest struct {
+	Query          string          `json:"query"`
+	RetrievalModel *RetrievalModel `json:"retrieval_model,omitempty"`
+}
+
+// RetrieveResponse 知识库检索响应
+type RetrieveResponse struct {
+	Query   RetrieveQuery     `json:"query"`
+	Records []RetrievalRecord `json:"records"`
+}
+
+// RetrieveQuery 检索问题
+type RetrieveQuery struct {
+	Content string `json:"content"`
+}
+
+// RetrievalRecord 检索命中记录
+type RetrievalRecord struct {
+	Segment      RetrievalSegment       `json:"segment"`
+	ChildChunks  []RetrievalChildChunk  `json:"child_chunks,omitempty"`
+	Score        float64                `json:"score"`
+	TsnePosition map[string]interface{} `json:"tsne_position,omitempty"`
+}
+
+// RetrievalSegment 命中的分段及所属文档
+type RetrievalSegment struct {
+	Segment
+	Document RetrievalDocument `json:"document"`
+}
+
+// RetrievalDocument 命中分段所属文档
+type RetrievalDocument struct {
+	ID             string                 `json:"id"`
+	DataSourceType string                 `json:"data_source_type"`
+	Name           string                 `json:"name"`
+	DocType        string                 `json:"doc_type,omitempty"`
+	DocMetadata    map[string]interface{} `json:"doc_metadata,omitempty"`
+}
+
+// RetrievalChildChunk 命中的子分段
+type RetrievalChildChunk struct {
+	ID       string  `json:"id"`
+	Content  string  `json:"content"`
+	Position int     `json:"position"`
+	Score    float64 `json:"score"`
+}
+
+// RetrieverResource 转换为对话响应中使用的引用资源
+func (r RetrievalRecord) RetrieverResource(datasetID, datasetName string) RetrieverResource {
+	return RetrieverResource{
+		Position:     r.Segment.Position,
+		DatasetID:    datasetID,
+		DatasetName:  datasetName,
+		DocumentID:   r.Segment.Document.ID,
+		DocumentName: r.Segment.Document.Name,
+		SegmentID:    r.Segment.ID,
+		Score:        r.Score,
+		Content:      r.Segment.Content,
+	}
+}
+
+// 元数据字段类型
+const (
+	MetadataTypeString = "string"
+	MetadataTypeNumber = "number"
+	MetadataTypeTime   = "time"
+)
+
+// MetadataField 知识库元数据字段
+type MetadataField struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Count int    `json:"count,omitempty"`
+}
+
+// CreateMetadataFieldRequest 创建元数据字段请求
+type CreateMetadataFieldRequest struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// MetadataFieldListResponse 元数据字段列表响应
+type MetadataFieldListResponse struct {
+	DocMetadata         []MetadataField `json:"doc_metadata"`
+	BuiltInFieldEnabled bool            `json:"built_in_field_enabled"`
+}
+
+// DocumentMetadataValue 文档的元数据值
+type DocumentMetadataValue struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// DocumentMetadataOperation 单个文档的元数据更新
+type DocumentMetadataOperation struct {
+	DocumentID   string                  `json:"document_id"`
+	MetadataList []DocumentMetadataValue `json:"metadata_list"`
+}
+
+// UpdateDocumentsMetadataRequest 批量更新文档元数据请求
+type UpdateDocumentsMetadataRequest struct {
+	OperationData []DocumentMetadataOperation `json:"operation_data"`
+}
@@ -0,0 +1,91 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Decode 按 value_type 将变量值解码为 Go 值
+//
+// string/secret 解码为 string, number 为 float64, boolean 为 bool,
+// object/file 为 map[string]interface{}, array[...] 为 []interface{}。
+// Dify 部分版本会将非字符串值序列化为 JSON 字符串返回, 此时会再解析一次。
+func (v *ConversationVariable) Decode() (interface{}, error) {
+	var value interface{}
+	if err := v.DecodeInto(&value); err != nil {
+		return nil, err
+	}
+
+	if s, ok := value.(string); ok && v.ValueType != VariableTypeString && v.ValueType != VariableTypeSecret {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(s), &parsed); err == nil {
+			return parsed, nil
+		}
+	}
+	return value, nil
+}
+
+// DecodeInto 将变量值解码到 target
+func (v *ConversationVariable) DecodeInto(target interface{}) error {
+	if len(v.Value) == 0 || string(v.Value) == "null" {
+		return nil
+	}
+
+	err := json.Unmarshal(v.Value, target)
+	if err == nil {
+		return nil
+	}
+
+	// 值被序列化为 JSON 字符串时再解析一次
+	var s string
+	if json.Unmarshal(v.Value, &s) == nil {
+		if err := json.Unmarshal([]byte(s), target); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to decode variable %s as %s: %w", v.Name, v.ValueType, err)
+}
+
+// GetConversationVariables 获取会话变量
+func (c *ChatClient) GetConversationVariables(ctx context.Context, conversationID string, user string, lastID string, limit int, variableName string) (*ConversationVariableListResponse, error) {
+	if user == "" {
+		user = DefaultUser
+	}
+
+	query := url.Values{}
+	query.Set("user", user)
+	query.Set("limit", strconv.Itoa(defaultLimit(limit)))
+	if lastID != "" {
+		query.Set("last_id", lastID)
+	}
+	if variableName != "" {
+		query.Set("variable_name", variableName)
+	}
+
+	var resp ConversationVariableListResponse
+	path := fmt.Sprintf("/conversations/%s/variables?%s", conversationID, query.Encode())
+	err := c.doRequestWithResponse(ctx, "GET", path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateConversationVariable 更新会话变量的值
+func (c *ChatClient) UpdateConversationVariable(ctx context.Context, conversationID, variableID string, value interface{}, user string) (*ConversationVariable, error) {
+	if user == "" {
+		user = DefaultUser
+	}
+
+	req := &UpdateConversationVariableRequest{Value: value, User: user}
+	var resp ConversationVariable
+	path := fmt.Sprintf("/conversations/%s/variables/%s", conversationID, variableID)
+	err := c.doRequestWithResponse(ctx, "PUT", path, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,125 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestConversationVariableDecode(t *testing.T) {
+	tests := []struct {
+		name      string
+		valueType string
+		value     string
+		want      interface{}
+	}{
+		{name: "string", valueType: VariableTypeString, value: `"hello"`, want: "hello"},
+		{name: "json-looking string", valueType: VariableTypeString, value: `"{\"a\":1}"`, want: `{"a":1}`},
+		{name: "secret", valueType: VariableTypeSecret, value: `"42"`, want: "42"},
+		{name: "number", valueType: VariableTypeNumber, value: `3.5`, want: 3.5},
+		{name: "number as string", valueType: VariableTypeNumber, value: `"3.5"`, want: 3.5},
+		{name: "boolean as string", valueType: VariableTypeBoolean, value: `"true"`, want: true},
+		{name: "object", valueType: VariableTypeObject, value: `{"city":"杭州"}`, want: map[string]interface{}{"city": "杭州"}},
+		{name: "object as string", valueType: VariableTypeObject, value: `"{\"city\":\"杭州\"}"`, want: map[string]interface{}{"city": "杭州"}},
+		{name: "array as string", valueType: VariableTypeArrayString, value: `"[\"a\",\"b\"]"`, want: []interface{}{"a", "b"}},
+		{name: "unparsable string kept", valueType: VariableTypeNumber, value: `"n/a"`, want: "n/a"},
+		{name: "null", valueType: VariableTypeObject, value: `null`, want: nil},
+		{name: "missing", valueType: VariableTypeObject, value: ``, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ConversationVariable{Name: "v", ValueType: tt.valueType, Value: json.RawMessage(tt.value)}
+			got, err := v.Decode()
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversationVariableDecodeInto(t *testing.T) {
+	type profile struct {
+		City string `json:"city"`
+		Age  int    `json:"age"`
+	}
+
+	for _, value := range []string{`{"city":"杭州","age":30}`, `"{\"city\":\"杭州\",\"age\":30}"`} {
+		v := &ConversationVariable{Name: "profile", ValueType: VariableTypeObject, Value: json.RawMessage(value)}
+		var got profile
+		if err := v.DecodeInto(&got); err != nil {
+			t.Fatalf("DecodeInto(%s) error = %v", value, err)
+		}
+		if got != (profile{City: "杭州", Age: 30}) {
+			t.Errorf("DecodeInto(%s) = %+v", value, got)
+		}
+	}
+
+	v := &ConversationVariable{Name: "count", ValueType: VariableTypeNumber, Value: json.RawMessage(`"many"`)}
+	var n int
+	if err := v.DecodeInto(&n); err == nil {
+		t.Error("DecodeInto() error = nil, want error for non-numeric value")
+	}
+}
+
+func TestConversationVariablesAPI(t *testing.T) {
+	var (
+		query url.Values
+		body  map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/conversations/conv/variables":
+			query = r.URL.Query()
+			w.Write([]byte(`{"limit":20,"has_more":false,"data":[{"id":"var-1","name":"profile","value_type":"object","value":"{\"city\":\"杭州\"}"}]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/conversations/conv/variables/var-1":
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &body)
+			w.Write([]byte(`{"id":"var-1","name":"profile","value_type":"object","value":{"city":"上海"}}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusTeapot)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewChatClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := client.GetConversationVariables(context.Background(), "conv", "u", "var-0", 0, "profile")
+	if err != nil {
+		t.Fatalf("GetConversationVariables() error = %v", err)
+	}
+	wantQuery := url.Values{"user": {"u"}, "limit": {"20"}, "last_id": {"var-0"}, "variable_name": {"profile"}}
+	if !reflect.DeepEqual(query, wantQuery) {
+		t.Errorf("query = %v, want %v", query, wantQuery)
+	}
+	if len(list.Data) != 1 {
+		t.Fatalf("variables = %d, want 1", len(list.Data))
+	}
+	value, err := list.Data[0].Decode()
+	if err != nil || !reflect.DeepEqual(value, map[string]interface{}{"city": "杭州"}) {
+		t.Errorf("Decode() = %v, %v", value, err)
+	}
+
+	updated, err := client.UpdateConversationVariable(context.Background(), "conv", "var-1", map[string]string{"city": "上海"}, "u")
+	if err != nil {
+		t.Fatalf("UpdateConversationVariable() error = %v", err)
+	}
+	wantBody := map[string]interface{}{"value": map[string]interface{}{"city": "上海"}, "user": "u"}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("body = %v, want %v", body, wantBody)
+	}
+	if value, _ := updated.Decode(); !reflect.DeepEqual(value, map[string]interface{}{"city": "上海"}) {
+		t.Errorf("updated value = %v", value)
+	}
+}
@@ -3,6 +3,9 @@ package dify
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"time"
 )
 
 // WorkflowClient 工作流应用客户端
@@ -27,7 +30,7 @@ func (c *WorkflowClient) Run(ctx context.Context, req *WorkflowRequest) (*Workfl
 	req.ResponseMode = "blocking"
 
 	var resp WorkflowResponse
-	err := c.doRequestWithResponse(ctx, "POST", "/workflows/run", req, &resp)
+	err := c.doRequestWithResponse(ctx, "POST", workflowRunPath(req), req, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -41,11 +44,19 @@ func (c *WorkflowClient) RunStream(ctx context.Context, req *WorkflowRequest) (*
 	}
 	req.ResponseMode = "streaming"
 
-	resp, err := c.doStreamRequest(ctx, "POST", "/workflows/run", req)
+	resp, err := c.doStreamRequest(ctx, "POST", workflowRunPath(req), req)
 	if err != nil {
 		return nil, err
 	}
-	return NewStreamReader(resp), nil
+	return c.newStreamReader(resp), nil
+}
+
+// workflowRunPath 返回执行工作流的路径, 指定 WorkflowID 时执行对应版本
+func workflowRunPath(req *WorkflowRequest) string {
+	if req.WorkflowID != "" {
+		return fmt.Sprintf("/workflows/%s/run", req.WorkflowID)
+	}
+	return "/workflows/run"
 }
 
 // Stop 停止工作流
@@ -73,6 +84,42 @@ func (c *WorkflowClient) GetRunStatus(ctx context.Context, workflowRunID string)
 	return &resp, nil
 }
 
+// GetLogs 获取工作流执行日志, opts 可为 nil
+func (c *WorkflowClient) GetLogs(ctx context.Context, opts *WorkflowLogListOptions) (*WorkflowLogListResponse, error) {
+	if opts == nil {
+		opts = &WorkflowLogListOptions{}
+	}
+
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(max(opts.Page, 1)))
+	query.Set("limit", strconv.Itoa(defaultLimit(opts.Limit)))
+	if opts.Keyword != "" {
+		query.Set("keyword", opts.Keyword)
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if !opts.CreatedAtBefore.IsZero() {
+		query.Set("created_at__before", opts.CreatedAtBefore.UTC().Format(time.RFC3339))
+	}
+	if !opts.CreatedAtAfter.IsZero() {
+		query.Set("created_at__after", opts.CreatedAtAfter.UTC().Format(time.RFC3339))
+	}
+	if opts.CreatedByEndUserSessionID != "" {
+		query.Set("created_by_end_user_session_id", opts.CreatedByEndUserSessionID)
+	}
+	if opts.CreatedByAccount != "" {
+		query.Set("created_by_account", opts.CreatedByAccount)
+	}
+
+	var resp WorkflowLogListResponse
+	err := c.doRequestWithResponse(ctx, "GET", "/workflows/logs?"+query.Encode(), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 // GetParameters 获取应用参数
 func (c *WorkflowClient) GetParameters(ctx context.Context, user string) (*AppParametersResponse, error) {
 	if user == "" {
@@ -0,0 +1,147 @@
+package dify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newWorkflowTestClient 创建指向测试服务器的工作流客户端
+func newWorkflowTestClient(t *testing.T, handler http.HandlerFunc) *WorkflowClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewWorkflowClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestWorkflowGetLogsQuery(t *testing.T) {
+	// 非 UTC 时区的时间转换为 UTC 后按 RFC3339 发送
+	shanghai := time.FixedZone("CST", 8*3600)
+
+	tests := []struct {
+		name string
+		opts *WorkflowLogListOptions
+		want url.Values
+	}{
+		{
+			name: "defaults",
+			want: url.Values{"page": {"1"}, "limit": {"20"}},
+		},
+		{
+			name: "time range and creators",
+			opts: &WorkflowLogListOptions{
+				Keyword:                   "invoice",
+				Status:                    WorkflowStatusFailed,
+				CreatedAtAfter:            time.Date(2025, 3, 1, 8, 0, 0, 0, shanghai),
+				CreatedAtBefore:           time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+				CreatedByEndUserSessionID: "session-1",
+				CreatedByAccount:          "ops@example.com",
+				Page:                      2,
+				Limit:                     50,
+			},
+			want: url.Values{
+				"page":                           {"2"},
+				"limit":                          {"50"},
+				"keyword":                        {"invoice"},
+				"status":                         {"failed"},
+				"created_at__after":              {"2025-03-01T00:00:00Z"},
+				"created_at__before":             {"2025-03-02T00:00:00Z"},
+				"created_by_end_user_session_id": {"session-1"},
+				"created_by_account":             {"ops@example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got url.Values
+			client := newWorkflowTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/workflows/logs" {
+					http.Error(w, "unexpected request", http.StatusTeapot)
+					return
+				}
+				got = r.URL.Query()
+				w.Write([]byte(`{"page":1,"limit":20,"total":1,"has_more":false,"data":[{"id":"log-1","workflow_run":{"id":"run-1","status":"failed","error":"timeout"}}]}`))
+			})
+
+			resp, err := client.GetLogs(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("GetLogs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query = %v, want %v", got, tt.want)
+			}
+			if len(resp.Data) != 1 || resp.Data[0].WorkflowRun.ID != "run-1" || resp.Data[0].WorkflowRun.Error != "timeout" {
+				t.Errorf("Data = %+v", resp.Data)
+			}
+		})
+	}
+}
+
+func TestWorkflowRunPath(t *testing.T) {
+	tests := []struct {
+		workflowID string
+		wantPath   string
+	}{
+		{workflowID: "", wantPath: "/workflows/run"},
+		{workflowID: "wf-v2", wantPath: "/workflows/wf-v2/run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantPath, func(t *testing.T) {
+			var paths []string
+			var bodies []map[string]any
+			client := newWorkflowTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				var body map[string]any
+				json.Unmarshal(data, &body)
+				paths = append(paths, r.Method+" "+r.URL.Path)
+				bodies = append(bodies, body)
+
+				if body["response_mode"] == "streaming" {
+					w.Header().Set("Content-Type", "text/event-stream")
+					fmt.Fprint(w, "data: {\"event\":\"workflow_finished\",\"task_id\":\"t\",\"workflow_run_id\":\"run-1\",\"data\":{\"id\":\"run-1\",\"status\":\"succeeded\"}}\n\n")
+					return
+				}
+				w.Write([]byte(`{"task_id":"t","workflow_run_id":"run-1","data":{"id":"run-1","status":"succeeded"}}`))
+			})
+
+			req := &WorkflowRequest{WorkflowID: tt.workflowID, Inputs: map[string]any{"q": "hi"}, User: "u"}
+			if _, err := client.Run(context.Background(), req); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			stream, err := client.RunStream(context.Background(), req)
+			if err != nil {
+				t.Fatalf("RunStream() error = %v", err)
+			}
+			if _, err := readEvents(stream); err != nil {
+				t.Fatalf("stream error = %v", err)
+			}
+			stream.Close()
+
+			want := []string{"POST " + tt.wantPath, "POST " + tt.wantPath}
+			if !reflect.DeepEqual(paths, want) {
+				t.Errorf("requests = %v, want %v", paths, want)
+			}
+			// WorkflowID 只出现在路径中, 不写入请求体
+			for _, body := range bodies {
+				if _, ok := body["workflow_id"]; ok {
+					t.Errorf("body = %v, want no workflow_id", body)
+				}
+			}
+		})
+	}
+}